@@ -0,0 +1,106 @@
+package avl
+
+import (
+	"fmt"
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// FitStrategy selects which free range an Allocator carves an allocation from
+type FitStrategy int
+
+const (
+	// Use the free range at the lowest offset that is large enough. The
+	// default: fast, and keeps allocations packed towards the start.
+	FirstFit FitStrategy = iota
+	// Use the smallest free range that is large enough, lowest offset first
+	// among equal sizes, to leave large ranges whole for large allocations.
+	// Every free range is considered, so allocating takes O(n) in the number
+	// of free ranges.
+	BestFit
+)
+
+// Allocator hands out ranges of an offset space [0, capacity), such as the
+// blocks of a file or the bytes of a buffer, and takes them back. Its free
+// space is kept in a CoalescingIntervalSet, so freed ranges merge with the
+// free space around them and the space doesn't fragment into ranges smaller
+// than what was allocated.
+type Allocator[T constraints.Integer] struct {
+	free     *CoalescingIntervalSet[T]
+	capacity T
+	strategy FitStrategy
+}
+
+// %% Public methods %%
+
+// Returns an allocator of the offsets [0, capacity), all of them free
+func NewAllocator[T constraints.Integer](capacity T, strategy FitStrategy) *Allocator[T] {
+	alloc := &Allocator[T]{free: NewCoalescingIntervalSet[T](), capacity: capacity, strategy: strategy}
+	alloc.free.Insert(0, capacity)
+	return alloc
+}
+
+// Allocate a range of size offsets, chosen by the allocator's strategy.
+// Returns the offset of the range, or an error wrapping ErrCapacityExceeded
+// if no free range is large enough.
+func (alloc *Allocator[T]) Allocate(size T) (T, error) {
+	if size <= 0 {
+		return 0, alloc.free.starts.misuse(fmt.Errorf("size %v is not positive: %w", size, ErrInvalidArgument))
+	}
+	found := false
+	var offset, best T
+	for start, end := range alloc.free.Intervals() {
+		if end-start < size || (found && end-start >= best) {
+			continue
+		}
+		offset, best, found = start, end-start, true
+		if alloc.strategy == FirstFit {
+			break
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("no free range of size %v: %w", size, ErrCapacityExceeded)
+	}
+	alloc.free.Remove(offset, offset+size)
+	return offset, nil
+}
+
+// Return the range of size offsets at offset to the free space. The range
+// need not match a single allocation, but all of it must be allocated.
+// Returns an error if the range is empty, out of bounds or partly free.
+func (alloc *Allocator[T]) Free(offset, size T) error {
+	if size <= 0 {
+		return alloc.free.starts.misuse(fmt.Errorf("size %v is not positive: %w", size, ErrInvalidArgument))
+	}
+	if offset < 0 || size > alloc.capacity || offset > alloc.capacity-size {
+		return alloc.free.starts.misuse(fmt.Errorf("range [%v, %v) is outside [0, %v): %w", offset, offset+size, alloc.capacity, ErrValueOutOfRange))
+	}
+	if alloc.free.overlaps(offset, offset+size) {
+		return alloc.free.starts.misuse(fmt.Errorf("range [%v, %v) is not allocated: %w", offset, offset+size, ErrInvalidRange))
+	}
+	alloc.free.Insert(offset, offset+size)
+	return nil
+}
+
+// Return the number of free offsets
+func (alloc *Allocator[T]) Available() T {
+	return alloc.free.TotalCovered()
+}
+
+// Return the size of the offset space
+func (alloc *Allocator[T]) Capacity() T {
+	return alloc.capacity
+}
+
+// Returns an iterator over the free ranges in ascending order, as the start
+// and end of each
+func (alloc *Allocator[T]) FreeRanges() iter.Seq2[T, T] {
+	return alloc.free.Intervals()
+}
+
+// Set how the allocator reports misuse, such as freeing a range that is
+// already free. See MisusePolicy.
+func (alloc *Allocator[T]) SetMisusePolicy(policy MisusePolicy) {
+	alloc.free.starts.SetMisusePolicy(policy)
+}
@@ -0,0 +1,55 @@
+package avl
+
+import (
+	"errors"
+	"testing"
+)
+
+// Test that each strategy picks the expected free range, and that freed
+// ranges merge back into the free space
+func TestAllocator(t *testing.T) {
+	for _, tc := range []struct {
+		strategy FitStrategy
+		expected int
+	}{
+		{FirstFit, 0},
+		{BestFit, 40},
+	} {
+		alloc := NewAllocator(100, tc.strategy)
+		offsets := make([]int, 0)
+		for _, size := range []int{10, 20, 10, 5, 55} {
+			offset, err := alloc.Allocate(size)
+			assert(err, nil, "alloc.Allocate() error", t)
+			offsets = append(offsets, offset)
+		}
+		assertSlice(offsets, []int{0, 10, 30, 40, 45}, "offsets of allocations filling the space", t)
+		_, err := alloc.Allocate(1)
+		assert(errors.Is(err, ErrCapacityExceeded), true, "alloc.Allocate() when full", t)
+
+		// Free ranges of 10 at 0 and of 5 at 40
+		alloc.Free(0, 10)
+		alloc.Free(40, 5)
+		offset, _ := alloc.Allocate(5)
+		assert(offset, tc.expected, "alloc.Allocate(5) between free ranges", t)
+		assert(alloc.Available(), 10, "alloc.Available()", t)
+	}
+
+	alloc := NewAllocator[uint](64, FirstFit)
+	a, _ := alloc.Allocate(16)
+	b, _ := alloc.Allocate(16)
+	alloc.Free(a, 16)
+	alloc.Free(b, 16)
+	assert(collectIntervals(alloc.FreeRanges())[0], [2]uint{0, 64}, "free ranges merged after Free", t)
+}
+
+// Test that invalid frees are reported and leave the free space unchanged
+func TestAllocatorMisuse(t *testing.T) {
+	alloc := NewAllocator[uint](64, FirstFit)
+	alloc.Allocate(32)
+	assert(errors.Is(alloc.Free(16, 32), ErrInvalidRange), true, "alloc.Free() of a partly free range", t)
+	assert(errors.Is(alloc.Free(60, 8), ErrValueOutOfRange), true, "alloc.Free() past the capacity", t)
+	assert(errors.Is(alloc.Free(0, 100), ErrValueOutOfRange), true, "alloc.Free() larger than the capacity", t)
+	_, err := alloc.Allocate(0)
+	assert(errors.Is(err, ErrInvalidArgument), true, "alloc.Allocate(0)", t)
+	assert(alloc.Available(), uint(32), "alloc.Available() after invalid frees", t)
+}
@@ -2,137 +2,360 @@ package avl
 
 import (
 	"fmt"
-	"math"
+	"math/bits"
+	"slices"
 
 	"golang.org/x/exp/constraints"
 )
 
-type Node[T constraints.Ordered] struct {
-	value  T
-	left   *Node[T]
-	right  *Node[T]
-	parent *Node[T]
-	height int
+type Node[T any] struct {
+	value T
+	// Height of the right subtree minus the height of the left subtree,
+	// between -1 and 1 outside of rebalancing
+	balance int8
+	// Left and right children, indexed by direction
+	children [2]*Node[T]
+	parent   *Node[T]
+	// Number of nodes in the subtree rooted at this node
+	count int
+}
+
+// Index of a child in Node.children. Mirrored cases of the algorithms are
+// written once in terms of a direction and its opposite.
+type direction int
+
+const (
+	dirLeft  direction = 0
+	dirRight direction = 1
+)
+
+// Returns the other direction
+func (dir direction) opposite() direction {
+	return 1 - dir
 }
 
-type AvlTree[T constraints.Ordered] struct {
+// Returns the sign of a subtree's height in the balance factor: -1 for the
+// left subtree and +1 for the right one
+func (dir direction) sign() int {
+	return 2*int(dir) - 1
+}
+
+// AvlTree is a self-balancing binary search tree of ordered values. Trees
+// created with NewAvlTree order values with <, and trees created with
+// NewAvlTreeFunc with a comparator, so they can hold any type.
+//
+// The tree uses no randomness: its shape is determined entirely by the
+// sequence of operations applied to it, so replaying the same operations
+// always produces the same structure. Iteration is always in ascending
+// order, with equal values in insertion order. The exact shape produced by a
+// given sequence is not part of the API and may change between versions;
+// use CanonicalForm for a shape that depends only on the tree's contents.
+type AvlTree[T any] struct {
 	root *Node[T]
 	size int
+	// Reports whether a sorts before b. Values for which neither sorts
+	// before the other are equal.
+	less func(a, b T) bool
+	// Height of the root, kept up to date as rebalancing reaches the root
+	height int
+	// Leftmost and rightmost nodes, indexed by direction, or nil for an
+	// empty tree. Kept up to date by every mutation so that the minimum and
+	// maximum are found without a descent.
+	edges   [2]*Node[T]
+	version uint64
+	log     *changeLog[T]
+	// How misuse by the caller is reported
+	misusePolicy MisusePolicy
+	// Bloom filter of the values, if enabled with WithNegativeLookupFilter
+	filter *negativeFilter[T]
+	// Whether descents check their depth, see SetHeightGuard
+	heightGuard bool
+	// The first corruption found by the height guard
+	corruption error
+	// Called after healing the tree, if self-healing is on
+	onHeal func(HealReport)
+	// Copies values on their way in and out, see WithCloneFunc
+	clone func(T) T
+	// Whether nil values are refused, see WithNilPolicy
+	rejectNil bool
+	// Whether the tree may hold equal values. Set when a mutation puts a
+	// value next to an equal one, and cleared only when the tree is emptied
+	// or rebuilt, so that a tree of distinct values can be told apart in O(1).
+	mayHoldDuplicates bool
+	// Counts changes to the tree's shape that leave its values alone, such as
+	// rotations, which invalidate iterators but not the version, so that
+	// versions count only the changes a follower has records for
+	reshapes uint64
 }
 
-type AvlTreeIterator[T constraints.Ordered] struct {
+type AvlTreeIterator[T any] struct {
 	tree  *AvlTree[T]
 	stack []*Node[T]
 	index int
+	// Version and shape of the tree the iterator was reset at
+	version  uint64
+	reshapes uint64
 }
 
 func (node *Node[T]) balanceFactor() int {
-	leftHeight, rightHeight := -1, -1
-	if node.left != nil {
-		leftHeight = node.left.height
+	return int(node.balance)
+}
+
+// %% Public methods %%
+
+func NewAvlTree[T constraints.Ordered](opts ...TreeOption) *AvlTree[T] {
+	var config treeConfig
+	for _, opt := range opts {
+		opt(&config)
 	}
-	if node.right != nil {
-		rightHeight = node.right.height
+	tree := newTree(func(a, b T) bool { return a < b })
+	tree.configure(config)
+	if config.negativeFilter {
+		tree.filter = newNegativeFilter[T]()
 	}
-	return rightHeight - leftHeight
+	return tree
 }
 
-// %% Public methods %%
+// Returns a tree ordering its values with less, which must be a strict weak
+// ordering: values for which neither less(a, b) nor less(b, a) holds are
+// treated as equal, so a Remove or Contains of one finds any of them. For
+// example, records sorted by timestamp:
+//
+//	tree := NewAvlTreeFunc(func(a, b Record) bool { return a.Time.Before(b.Time) })
+//
+// Options are applied as with NewAvlTree, except WithNegativeLookupFilter,
+// which needs values it can hash and is ignored.
+func NewAvlTreeFunc[T any](less func(a, b T) bool, opts ...TreeOption) *AvlTree[T] {
+	var config treeConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	tree := newTree(less)
+	tree.configure(config)
+	return tree
+}
+
+// Lesser is implemented by element types that order themselves with a Less
+// method, such as netip.Addr.
+type Lesser[T any] interface {
+	Less(T) bool
+}
+
+// Returns a new, empty tree of values ordered by their Less method, as an
+// alternative to passing NewAvlTreeFunc a closure. See NewAvlTreeFunc.
+func NewAvlTreeOrderedBy[T Lesser[T]](opts ...TreeOption) *AvlTree[T] {
+	return NewAvlTreeFunc(T.Less, opts...)
+}
+
+// Returns a tree holding the values of a slice already in ascending order,
+// built as a perfectly balanced tree in O(n) rather than by n calls to Add.
+// Options are applied as with NewAvlTree, and the slice is not retained.
+// Returns an error wrapping ErrInvalidArgument if the values are out of order.
+func NewFromSortedSlice[T constraints.Ordered](values []T, opts ...TreeOption) (*AvlTree[T], error) {
+	for i := 1; i < len(values); i++ {
+		if values[i] < values[i-1] {
+			return nil, fmt.Errorf("%w: value %v at index %d follows %v", ErrInvalidArgument, values[i], i, values[i-1])
+		}
+	}
+	tree := NewAvlTree[T](opts...)
+	tree.replaceValues(values)
+	if tree.filter != nil {
+		tree.rebuildFilter()
+	}
+	return tree, nil
+}
+
+// Returns a tree holding the values of a slice in any order, sorting a copy of
+// it and building a balanced tree in O(n log n), which is faster than adding
+// the values one at a time. Options are applied as with NewAvlTree.
+func NewFromSlice[T constraints.Ordered](values []T, opts ...TreeOption) *AvlTree[T] {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	tree, _ := NewFromSortedSlice(sorted, opts...)
+	return tree
+}
 
-func NewAvlTree[T constraints.Ordered]() *AvlTree[T] {
-	return &AvlTree[T]{root: nil}
+// Returns a tree holding each distinct value of a slice once, like
+// NewFromSlice
+func NewFromSliceUnique[T constraints.Ordered](values []T, opts ...TreeOption) *AvlTree[T] {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	tree, _ := NewFromSortedSlice(slices.Compact(sorted), opts...)
+	return tree
 }
 
 // Insert a node with the given value and rebalance the tree.
+// Duplicate values are kept. A value is placed after any equal values already
+// in the tree, and rotations preserve in-order position, so equal values are
+// always traversed in insertion (FIFO) order. With the height guard on, use
+// TryAdd to learn whether the value was added to a corrupt tree. Panics if
+// the value is nil and the tree's nil policy is NilRejected, whatever the
+// misuse policy, since the value can't be added and there is no error to
+// return; use TryAdd to get the error instead.
 func (tree *AvlTree[T]) Add(value T) {
-	newNode, parent := tree.insertNode(value)
-	newNode.parent = parent
+	if err := tree.checkValue(value); err != nil {
+		panic(err)
+	}
+	tree.TryAdd(value)
+}
+
+// Add the value as Add does, reporting an insertion the height guard
+// abandoned on a corrupt tree: returns an error wrapping ErrCorruptTree
+// according to the misuse policy, in which case the value is not added.
+// Without the height guard, or with self-healing on, TryAdd only fails for
+// nil values refused under NilRejected, with an error wrapping
+// ErrInvalidArgument.
+func (tree *AvlTree[T]) TryAdd(value T) error {
+	_, err := tree.addNode(value)
+	return err
+}
 
-	for parent != nil {
-		tree.rebalance(parent)
-		parent = parent.parent
+// Insert a value that is greater than or equal to every value in the tree,
+// such as the next timestamp of a time series. The new node is attached below
+// the maximum without comparing the value against the nodes above it, and
+// rebalancing after a run of appends takes amortized O(1) rotations.
+// Returns an error wrapping ErrValueOutOfRange if the value is less than the
+// maximum, in which case it is not added.
+func (tree *AvlTree[T]) AppendMax(value T) error {
+	last := tree.maxNode()
+	if last == nil {
+		return tree.TryAdd(value)
 	}
+	if err := tree.checkValue(value); err != nil {
+		return tree.misuse(err)
+	}
+	if tree.less(value, last.value) {
+		return tree.misuse(fmt.Errorf("%w: %v is less than the maximum %v", ErrValueOutOfRange, value, last.value))
+	}
+	if tree.clone != nil {
+		value = tree.clone(value)
+	}
+
+	if !tree.less(last.value, value) {
+		tree.mayHoldDuplicates = true
+	}
+	newNode := newTreeNode(value)
+	newNode.parent = last
+	last.children[dirRight] = newNode
+	tree.edges[dirRight] = newNode
+	for node := last; node != nil; node = node.parent {
+		node.count += 1
+	}
+	tree.retrace(last, dirRight, 1)
 	tree.size += 1
+	tree.recordChange(ChangeAdd, value)
+	return nil
 }
 
-// Remove a node by value lookup and rebalance the tree.
+// Remove a node by value lookup and rebalance the tree. Of several equal
+// values, the first in order (the earliest added) is removed.
 // Returns true on successful removal, false if value was not found.
 func (tree *AvlTree[T]) Remove(value T) bool {
 	node := tree.getNodeByValue(value)
 	if node == nil { // value was not found in the tree
 		return false
 	}
+	tree.removeNode(node)
+	return true
+}
 
-	parent := node.parent
-	var replacement *Node[T]
-
-	// Action node is the node where the rebalancing will start
-	actionNode := parent
-
-	// Case 1: two children, replace with in-order successor, then rebalance
-	if node.left != nil && node.right != nil {
-
-		// Find in-order successor (move right once then left all the way down)
-		successor := node.right
-		for successor.left != nil {
-			successor = successor.left
-		}
-
-		// Assign the children of the node to remove to the successor node
-		successor.left = node.left
-		// If the successor wasn't the right node, then we need to give it a
-		// right node. Otherwise, the successor's right node will be nil
-		if successor != node.right {
-			// We moved all the way down to the left.
-			// If the successor has a right node, put that right node in the
-			// successor's current spot
-			successor.parent.left = successor.right
-			if successor.right != nil {
-				successor.right.parent = successor.parent
-			}
-			// The successor now has both the node's children as its own
-			successor.right = node.right
-		}
-		// Complete the child->parent relationship
-		node.left.parent = successor
-		node.right.parent = successor
-
-		replacement = successor
-
-		actionNode = replacement.parent
-	} else {
-		// Case 2: one or no children, replace with existing child
-		if node.left == nil {
-			replacement = node.right
-		} else if node.right == nil {
-			replacement = node.left
-		}
-	}
-
-	tree.replaceChild(parent, node, replacement)
-	if replacement != nil {
-		replacement.parent = parent
+// Remove and return the minimum value in the tree
+func (tree *AvlTree[T]) PopMin() (T, error) {
+	node := tree.minNode()
+	if node == nil {
+		var zero T
+		return zero, ErrEmptyTree
 	}
+	tree.removeNode(node)
+	return node.value, nil
+}
 
-	// Rebalance from the parent of the node that got moved, up to the root
-	for actionNode != nil {
-		tree.rebalance(actionNode)
-		actionNode = actionNode.parent
+// Remove and return the maximum value in the tree
+func (tree *AvlTree[T]) PopMax() (T, error) {
+	node := tree.maxNode()
+	if node == nil {
+		var zero T
+		return zero, ErrEmptyTree
 	}
-
-	tree.size -= 1
-	return true
+	tree.removeNode(node)
+	return node.value, nil
 }
 
 // Returns a bool indicating whether the value exists in the tree
 func (tree *AvlTree[T]) Contains(value T) bool {
+	if tree.filter != nil && !tree.filter.mayContain(value) {
+		return false
+	}
 	return tree.getNodeByValue(value) != nil
 }
 
+// Returns the first node in order holding a value equal to the given value,
+// or nil if the value was not found
+func (tree *AvlTree[T]) FindNode(value T) *Node[T] {
+	return tree.getNodeByValue(value)
+}
+
+// Replace the value held by a node with one that sorts to the same position,
+// e.g. to update metadata on a record whose sort key is unchanged, without
+// the removal and insertion that would otherwise be needed. The new value
+// must sort no earlier than the previous node's value and no later than the
+// next node's. The update is recorded in the change log as a ChangeUpdate
+// with the node's rank, so that replaying it updates the same node even
+// among equal values.
+// Returns an error if the node is nil or doesn't belong to the tree, or one
+// wrapping ErrValueOutOfRange if the value would break the order, in which
+// case the node is left unchanged.
+func (tree *AvlTree[T]) UpdateValue(node *Node[T], value T) error {
+	if node == nil {
+		return tree.misuse(fmt.Errorf("node is nil: %w", ErrInvalidNode))
+	}
+	if !tree.ownsNode(node) {
+		return tree.misuse(fmt.Errorf("node does not belong to this tree: %w", ErrInvalidNode))
+	}
+	if err := tree.checkValue(value); err != nil {
+		return tree.misuse(err)
+	}
+	if err := tree.checkUpdate(node, value); err != nil {
+		return tree.misuse(err)
+	}
+	if tree.clone != nil {
+		value = tree.clone(value)
+	}
+	tree.updateNode(node, nodeRank(node), value)
+	return nil
+}
+
 // Clear the tree, removing all nodes
 func (tree *AvlTree[T]) Clear() {
 	tree.root = nil
 	tree.size = 0
+	tree.height = -1
+	tree.edges = [2]*Node[T]{}
+	tree.mayHoldDuplicates = false
+	var zero T
+	tree.recordChange(ChangeClear, zero)
+}
+
+// Clear the tree like Clear, but first walk it and unlink every node from its
+// parent and children. Huge trees are then released as independent nodes
+// rather than left for the GC to discover as one giant linked structure, and
+// any node pointers still held elsewhere don't keep the rest of the tree alive.
+func (tree *AvlTree[T]) ClearAndScrub() {
+	stack := make([]*Node[T], 0)
+	if tree.root != nil {
+		stack = append(stack, tree.root)
+	}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, child := range node.children {
+			if child != nil {
+				stack = append(stack, child)
+			}
+		}
+		node.children, node.parent = [2]*Node[T]{}, nil
+	}
+	tree.Clear()
 }
 
 // Returns a bool indicating whether the tree is empty
@@ -142,43 +365,69 @@ func (tree *AvlTree[T]) IsEmpty() bool {
 
 // Return the minimum value in the tree
 func (tree *AvlTree[T]) GetMin() (T, error) {
-	curr := tree.root
-	for curr != nil && curr.left != nil {
-		curr = curr.left
-	}
-
+	curr := tree.minNode()
 	if curr == nil {
 		var zero T
-		return zero, fmt.Errorf("tree is empty")
+		return zero, ErrEmptyTree
 	}
 	return curr.value, nil
 }
 
 // Return the maximum value in the tree
 func (tree *AvlTree[T]) GetMax() (T, error) {
-	curr := tree.root
-	for curr != nil && curr.right != nil {
-		curr = curr.right
-	}
+	curr := tree.maxNode()
 	if curr == nil {
 		var zero T
-		return zero, fmt.Errorf("tree is empty")
+		return zero, ErrEmptyTree
 	}
 	return curr.value, nil
 }
 
+// Return the height of the tree: the number of edges on the longest path
+// from the root to a leaf, or -1 for an empty tree
+func (tree *AvlTree[T]) Height() int {
+	return tree.height
+}
+
+// Return the tree's version, a counter incremented by every mutation. Two
+// reads of the same tree returning the same version saw the same values.
+func (tree *AvlTree[T]) Version() uint64 {
+	return tree.version
+}
+
 // Return the number of nodes in the tree
 func (tree *AvlTree[T]) Size() int {
 	return tree.size
 }
 
+// Return the number of nodes in the tree. Same as Size, named to match the
+// len convention of Go containers.
+func (tree *AvlTree[T]) Len() int {
+	return tree.size
+}
+
+// Return the minimum value in the tree. Same as GetMin.
+func (tree *AvlTree[T]) Min() (T, error) {
+	return tree.GetMin()
+}
+
+// Return the maximum value in the tree. Same as GetMax.
+func (tree *AvlTree[T]) Max() (T, error) {
+	return tree.GetMax()
+}
+
+// Returns the tree's values in-order, formatted like a slice, e.g. [1 2 3]
+func (tree *AvlTree[T]) String() string {
+	return fmt.Sprint(tree.InOrderTraverse())
+}
+
 func (tree *AvlTree[T]) inOrderTraverseHelper(node *Node[T], queue *[]T) []T {
 	if node == nil {
 		return *queue
 	}
-	*queue = tree.inOrderTraverseHelper(node.left, queue)
+	*queue = tree.inOrderTraverseHelper(node.children[dirLeft], queue)
 	*queue = append(*queue, node.value)
-	*queue = tree.inOrderTraverseHelper(node.right, queue)
+	*queue = tree.inOrderTraverseHelper(node.children[dirRight], queue)
 	return *queue
 }
 
@@ -187,27 +436,55 @@ func (tree *AvlTree[T]) inOrderTraverseHelper(node *Node[T], queue *[]T) []T {
 func (tree *AvlTree[T]) InOrderTraverse() []T {
 	queue := &[]T{}
 	tree.inOrderTraverseHelper(tree.root, queue)
+	if tree.clone != nil {
+		for i, v := range *queue {
+			(*queue)[i] = tree.clone(v)
+		}
+	}
 	return *queue
 }
 
-// Returns a new iterator for the tree. Call Next() on the iterator
-// to get the next value in the tree in-order.
+// Returns a new tree with the same values in canonical form: a perfectly
+// balanced tree whose shape depends only on its values, not on the order of
+// the operations that produced them, and that is stable across versions of
+// this package. Two trees holding the same values have identical canonical
+// forms, which makes them suitable for golden tests of serialized trees.
+func (tree *AvlTree[T]) CanonicalForm() *AvlTree[T] {
+	canonical := newTree(tree.less)
+	canonical.root = buildFromSorted(tree.InOrderTraverse(), nil)
+	canonical.size = tree.size
+	canonical.mayHoldDuplicates = tree.mayHoldDuplicates
+	canonical.height = subtreeHeight(canonical.root)
+	canonical.resetEdges()
+	return canonical
+}
+
+// Returns a new iterator for the tree, positioned before its first value.
+// Call Next() on the iterator to get the next value in the tree in-order.
+// The iterator follows the tree as it was when the iterator was created or
+// last reset; once the tree is modified, Next reports the end of the tree and
+// Err reports the modification.
 func (tree *AvlTree[T]) NewIterator() *AvlTreeIterator[T] {
-	return &AvlTreeIterator[T]{
-		tree:  tree,
-		stack: make([]*Node[T], 0),
-		index: 0,
+	iter := &AvlTreeIterator[T]{
+		tree: tree,
+		// Never holds more than one node per level
+		stack: make([]*Node[T], 0, tree.Height()+1),
 	}
+	iter.Reset()
+	return iter
 }
 
 // Print the tree in-order
+//
+// Deprecated: Use Print, which writes to any io.Writer and doesn't require
+// passing the root node.
 func (tree *AvlTree[T]) PrintTree(node *Node[T]) {
 	if node == nil {
 		return
 	}
-	tree.PrintTree(node.left)
+	tree.PrintTree(node.children[dirLeft])
 	fmt.Println(node.value)
-	tree.PrintTree(node.right)
+	tree.PrintTree(node.children[dirRight])
 }
 
 // %%% Iterator public methods %%%
@@ -216,24 +493,8 @@ func (tree *AvlTree[T]) PrintTree(node *Node[T]) {
 // from the iterator. If the end of the tree is reached, the zero value of the
 // type is returned and -1 is returned as the index.
 func (iter *AvlTreeIterator[T]) Next() (T, int) {
-	if iter.index == 0 {
-
-		// Handle empty tree
-		if iter.tree.root == nil {
-			var zero T
-			return zero, -1
-		}
-
-		// Push root and all left children onto stack
-		curr := iter.tree.root
-		for curr != nil {
-			iter.stack = append(iter.stack, curr)
-			curr = curr.left
-		}
-	}
-
-	// End of tree reached
-	if iter.index >= iter.tree.size {
+	// End of tree reached, or the tree changed under the iterator
+	if len(iter.stack) == 0 || iter.stale() {
 		var zero T
 		return zero, -1
 	}
@@ -243,136 +504,665 @@ func (iter *AvlTreeIterator[T]) Next() (T, int) {
 	iter.stack = iter.stack[:len(iter.stack)-1]
 
 	// Push right child and all its left children
-	curr := nextNode.right
-	for curr != nil {
-		iter.stack = append(iter.stack, curr)
-		curr = curr.left
-	}
+	iter.pushLeftSpine(nextNode.children[dirRight])
 
 	index := iter.index
 	iter.index += 1
 	return nextNode.value, index
 }
 
+// Move the iterator back before the first value of the tree, picking up any
+// changes made to the tree since it was created. The iterator's stack is
+// reused, so resetting doesn't allocate unless the tree grew taller.
+func (iter *AvlTreeIterator[T]) Reset() {
+	iter.stack = iter.stack[:0]
+	iter.index = 0
+	iter.version, iter.reshapes = iter.tree.version, iter.tree.reshapes
+	iter.pushLeftSpine(iter.tree.root)
+}
+
+// Returns ErrConcurrentModification if the tree was modified since the
+// iterator was created or last reset, which ends the iteration early, or nil
+func (iter *AvlTreeIterator[T]) Err() error {
+	if iter.stale() {
+		return ErrConcurrentModification
+	}
+	return nil
+}
+
+// Returns a bool indicating whether the tree changed since the last reset
+func (iter *AvlTreeIterator[T]) stale() bool {
+	return iter.tree.version != iter.version || iter.tree.reshapes != iter.reshapes
+}
+
+// Push the node and all its left descendants onto the stack
+func (iter *AvlTreeIterator[T]) pushLeftSpine(node *Node[T]) {
+	for curr := node; curr != nil; curr = curr.children[dirLeft] {
+		iter.stack = append(iter.stack, curr)
+	}
+}
+
+// %%% Node public methods %%%
+
+// Returns the value held by the node
+func (node *Node[T]) Value() T {
+	return node.value
+}
+
+// Returns the node's value, height and balance factor, and its position
+// relative to its parent, e.g. "5 (h=1 bf=+0 left of 8)"
+func (node *Node[T]) String() string {
+	if node == nil {
+		return "<nil>"
+	}
+	position := "root"
+	if node.parent != nil {
+		side := "right"
+		if node.parent.sideOf(node) == dirLeft {
+			side = "left"
+		}
+		position = fmt.Sprintf("%s of %v", side, node.parent.value)
+	}
+	return fmt.Sprintf("%v (h=%d bf=%+d %s)", node.value, subtreeHeight(node), node.balanceFactor(), position)
+}
+
 // %%% Node private methods %%%
 
-func newTreeNode[T constraints.Ordered](value T) *Node[T] {
-	return &Node[T]{value: value, height: 0}
+func newTreeNode[T any](value T) *Node[T] {
+	return &Node[T]{value: value, count: 1}
 }
 
-func (node *Node[T]) rotateLeft() *Node[T] {
-	child := node.right
-	node.right = child.left
-	if node.right != nil {
-		node.right.parent = node
+// Rotate the node in the given direction, moving its child on the opposite
+// side into its place. Returns the new root of the subtree and the change in
+// the subtree's height.
+func (node *Node[T]) rotate(dir direction) (*Node[T], int) {
+	up := dir.opposite()
+	child := node.children[up]
+
+	// Balance factors as seen from the rising side, and heights of the
+	// subtree and of the child's subtrees relative to the node's subtree on
+	// the other side
+	tilt := int(node.balance) * up.sign()
+	childTilt := int(child.balance) * up.sign()
+	height := max(tilt, 0) + 1
+	inner := tilt - 1 - max(childTilt, 0)
+	outer := inner + childTilt
+
+	node.children[up] = child.children[dir]
+	if node.children[up] != nil {
+		node.children[up].parent = node
 	}
-	child.left = node
+	child.children[dir] = node
 	node.parent = child
-	node.updateHeight()
-	child.updateHeight()
-	return child
+	// New balance factors follow from the heights of the three subtrees that
+	// moved, relative to each other
+	tilt = tilt - 1 - max(childTilt, 0)
+	childTilt = childTilt - 1 + min(tilt, 0)
+	node.balance = int8(tilt * up.sign())
+	child.balance = int8(childTilt * up.sign())
+	node.update()
+	child.update()
+	return child, max(max(inner, 0)+1, outer) + 1 - height
 }
 
-func (node *Node[T]) rotateRight() *Node[T] {
-	child := node.left
-	node.left = child.right
-	if node.left != nil {
-		node.left.parent = node
+// Set both children of the node, linking them back to it, and update its
+// subtree size. The caller is responsible for the balance factor.
+func (node *Node[T]) setChildren(left, right *Node[T]) {
+	node.children = [2]*Node[T]{left, right}
+	for _, child := range node.children {
+		if child != nil {
+			child.parent = node
+		}
 	}
-	child.right = node
-	node.parent = child
-	node.updateHeight()
-	child.updateHeight()
-	return child
+	node.update()
 }
 
-func (node *Node[T]) updateHeight() {
-	if node == nil {
-		return
+// Returns the side of the node the child is on
+func (node *Node[T]) sideOf(child *Node[T]) direction {
+	if node.children[dirLeft] == child {
+		return dirLeft
 	}
-	leftHeight, rightHeight := -1, -1
-	if node.left != nil {
-		leftHeight = node.left.height
+	return dirRight
+}
+
+// Returns the height of the subtree rooted at the node, or -1 for a nil
+// node, by following the taller child at each level
+func subtreeHeight[T any](node *Node[T]) int {
+	height := -1
+	for node != nil {
+		height += 1
+		if node.balance < 0 {
+			node = node.children[dirLeft]
+		} else {
+			node = node.children[dirRight]
+		}
 	}
-	if node.right != nil {
-		rightHeight = node.right.height
+	return height
+}
+
+// Returns the number of nodes in the subtree rooted at the node
+func nodeCount[T any](node *Node[T]) int {
+	if node == nil {
+		return 0
 	}
-	node.height = int(math.Max(float64(leftHeight), float64(rightHeight))) + 1
+	return node.count
+}
+
+// Recompute the node's subtree size from its children
+func (node *Node[T]) update() {
+	if node == nil {
+		return
+	}
+	node.count = nodeCount(node.children[dirLeft]) + nodeCount(node.children[dirRight]) + 1
 }
 
 // %%% Tree private methods %%%
 
-// Insert a node on the tree while maintaining the binary search tree property
-// Returns the inserted node and its parent.
-func (tree *AvlTree[T]) insertNode(value T) (*Node[T], *Node[T]) {
+// Add the value as TryAdd does, returning its new node
+func (tree *AvlTree[T]) addNode(value T) (*Node[T], error) {
+	if err := tree.checkValue(value); err != nil {
+		return nil, tree.misuse(err)
+	}
+	if tree.clone != nil {
+		value = tree.clone(value)
+	}
+	newNode, parent, err := tree.insertNode(value)
+	if err != nil {
+		return nil, err
+	}
+	newNode.parent = parent
+	if parent == nil {
+		tree.height = 0
+	}
+	for _, dir := range [2]direction{dirLeft, dirRight} {
+		if parent == tree.edges[dir] && (parent == nil || parent.children[dir] == newNode) {
+			tree.edges[dir] = newNode
+		}
+	}
+
+	// Subtree sizes were updated on the way down, so only balance factors
+	// are left to update, and only up to where the new level is absorbed
+	if parent != nil {
+		tree.retrace(parent, parent.sideOf(newNode), 1)
+	}
+	// Equal values go after those already in the tree, so only the previous
+	// node can be equal
+	if prev := prevNode(newNode); prev != nil && !tree.less(prev.value, value) {
+		tree.mayHoldDuplicates = true
+	}
+	tree.size += 1
+	tree.recordChange(ChangeAdd, value)
+	return newNode, nil
+}
+
+// Insert a node on the tree while maintaining the binary search tree property,
+// counting the new node in the subtree size of each node on its path.
+// Returns the inserted node and its parent, or the error from the height
+// guard if the descent was abandoned.
+func (tree *AvlTree[T]) insertNode(value T) (*Node[T], *Node[T], error) {
 	newNode := newTreeNode(value)
 	if tree.root == nil {
 		tree.root = newNode
-		return newNode, nil
+		return newNode, nil, nil
 	}
 
 	var parent *Node[T]
 	next := tree.root
-	for next != nil {
+	for depth, limit := 0, tree.depthLimit(); next != nil; depth++ {
+		if depth > limit {
+			tree.uncountDescent(value, depth)
+			if err := tree.detectedCorruption(depth, limit); err != nil {
+				return nil, nil, err
+			}
+			return tree.insertNode(value)
+		}
 		parent = next
-		if value < next.value {
-			next = next.left
-		} else {
-			next = next.right
+		parent.count += 1
+		next = next.children[tree.insertDirection(value, next)]
+	}
+	parent.children[tree.insertDirection(value, parent)] = newNode
+	return newNode, parent, nil
+}
+
+// Returns the side of the node an insertion of the value descends to. Equal
+// values descend right so they end up after existing ones.
+func (tree *AvlTree[T]) insertDirection(value T, node *Node[T]) direction {
+	if tree.less(value, node.value) {
+		return dirLeft
+	}
+	return dirRight
+}
+
+// Take back the subtree sizes counted by the first steps of an abandoned
+// insertion of the value, by retracing them from the root
+func (tree *AvlTree[T]) uncountDescent(value T, steps int) {
+	for curr := tree.root; steps > 0; steps-- {
+		curr.count -= 1
+		curr = curr.children[tree.insertDirection(value, curr)]
+	}
+}
+
+// Returns an error wrapping ErrValueOutOfRange if the value can't replace the
+// node's without breaking the order
+func (tree *AvlTree[T]) checkUpdate(node *Node[T], value T) error {
+	if prev := prevNode(node); prev != nil && tree.less(value, prev.value) {
+		return fmt.Errorf("%w: %v sorts before the previous value %v", ErrValueOutOfRange, value, prev.value)
+	}
+	if next := nextNode(node); next != nil && tree.less(next.value, value) {
+		return fmt.Errorf("%w: %v sorts after the next value %v", ErrValueOutOfRange, value, next.value)
+	}
+	return nil
+}
+
+// Replace the value of the node at the given rank and record the update
+func (tree *AvlTree[T]) updateNode(node *Node[T], rank int, value T) {
+	node.value = value
+	for _, adjacent := range [2]*Node[T]{prevNode(node), nextNode(node)} {
+		if adjacent != nil && tree.equal(adjacent.value, value) {
+			tree.mayHoldDuplicates = true
 		}
 	}
+	tree.recordChangeAt(ChangeUpdate, rank, value)
+}
+
+// Remove a node from the tree and rebalance from its parent up to the root
+func (tree *AvlTree[T]) removeNode(node *Node[T]) {
+	// Only the change log needs the rank of the removed value
+	rank := 0
+	if tree.log != nil {
+		rank = nodeRank(node)
+	}
+	tree.unlinkNode(node)
+	tree.size -= 1
+	tree.recordChangeAt(ChangeRemove, rank, node.value)
+}
 
-	if value < parent.value {
-		parent.left = newNode
+// Unlink a node from the tree and rebalance, without updating the size or
+// recording the change
+func (tree *AvlTree[T]) unlinkNode(node *Node[T]) {
+	// The neighbor of a removed edge node becomes the new edge
+	for _, dir := range [2]direction{dirLeft, dirRight} {
+		if tree.edges[dir] == node {
+			tree.edges[dir] = adjacentNode(node, dir.opposite())
+		}
+	}
+
+	parent := node.parent
+	var replacement *Node[T]
+
+	// Action node is the node where the rebalancing will start, and shrunk
+	// is the side of it that lost a level
+	actionNode := parent
+	var shrunk direction
+	if parent != nil {
+		shrunk = parent.sideOf(node)
+	}
+
+	// Case 1: two children, replace with in-order successor, then rebalance
+	if node.children[dirLeft] != nil && node.children[dirRight] != nil {
+
+		// Find in-order successor (move right once then left all the way down)
+		successor := node.children[dirRight]
+		for successor.children[dirLeft] != nil {
+			successor = successor.children[dirLeft]
+		}
+
+		if successor == node.children[dirRight] {
+			// The successor keeps its right subtree, which is now one level
+			// shorter than the node's right subtree was
+			actionNode, shrunk = successor, dirRight
+		} else {
+			// We moved all the way down to the left.
+			// If the successor has a right node, put that right node in the
+			// successor's current spot
+			actionNode, shrunk = successor.parent, dirLeft
+			successor.parent.children[dirLeft] = successor.children[dirRight]
+			if successor.children[dirRight] != nil {
+				successor.children[dirRight].parent = successor.parent
+			}
+			// The successor now has both the node's children as its own
+			successor.children[dirRight] = node.children[dirRight]
+			node.children[dirRight].parent = successor
+		}
+		// Complete the child->parent relationship
+		successor.children[dirLeft] = node.children[dirLeft]
+		node.children[dirLeft].parent = successor
+		successor.balance = node.balance
+
+		replacement = successor
 	} else {
-		parent.right = newNode
+		// Case 2: one or no children, replace with existing child
+		replacement = node.children[dirLeft]
+		if replacement == nil {
+			replacement = node.children[dirRight]
+		}
 	}
-	return newNode, parent
+
+	tree.replaceChild(parent, node, replacement)
+	if replacement != nil {
+		replacement.parent = parent
+	}
+
+	// Update subtree sizes up to the root before any rotation reads them
+	for curr := actionNode; curr != nil; curr = curr.parent {
+		curr.update()
+	}
+	if actionNode == nil {
+		// The root was replaced by its only child, if any
+		tree.height -= 1
+	}
+	tree.retrace(actionNode, shrunk, -1)
 }
 
-func (tree *AvlTree[T]) getNodeByValue(value T) *Node[T] {
-	if tree.root == nil {
+// Walk up from a node whose subtree on one side changed height by delta,
+// updating balance factors and rotating unbalanced nodes until the change in
+// height is absorbed, or reaches the root and so the tree's height.
+// Insertions need at most one rotation, which restores the height the
+// subtree had before.
+func (tree *AvlTree[T]) retrace(node *Node[T], dir direction, delta int) {
+	for node != nil && delta != 0 {
+		delta = node.adjustBalance(dir, delta)
+		if node.balance < -1 || node.balance > 1 {
+			var rotated int
+			node, rotated = tree.rebalance(node)
+			delta += rotated
+		}
+		if node.parent == nil {
+			tree.height += delta
+			return
+		}
+		dir = node.parent.sideOf(node)
+		node = node.parent
+	}
+}
+
+// Update the balance factor for a change in height of the node's subtree in
+// the given direction. Returns the resulting change in the node's height.
+func (node *Node[T]) adjustBalance(dir direction, delta int) int {
+	// Heights relative to the subtree on the other side
+	tilt := int(node.balance) * dir.sign()
+	node.balance += int8(delta * dir.sign())
+	return max(0, tilt+delta) - max(0, tilt)
+}
+
+// Returns the node at the given in-order index, or nil if out of range
+func (tree *AvlTree[T]) nodeAt(index int) *Node[T] {
+	if index < 0 || index >= tree.size {
 		return nil
 	}
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return tree.nodeAt(index)
+			}
+			return nil
+		}
+		leftCount := nodeCount(curr.children[dirLeft])
+		if index < leftCount {
+			curr = curr.children[dirLeft]
+		} else if index > leftCount {
+			index -= leftCount + 1
+			curr = curr.children[dirRight]
+		} else {
+			break
+		}
+	}
+	return curr
+}
 
-	node := tree.root
-	for node != nil {
-		if node.value == value {
-			return node
+// Returns the index of the node in sorted order, counting the nodes before it
+// in its left subtree and on the left of its path to the root
+func nodeRank[T any](node *Node[T]) int {
+	rank := nodeCount(node.children[dirLeft])
+	for ; node.parent != nil; node = node.parent {
+		if node.parent.children[dirRight] == node {
+			rank += nodeCount(node.parent.children[dirLeft]) + 1
+		}
+	}
+	return rank
+}
+
+// Returns the first node with a value greater than or equal to the given
+// value, or nil if there is none
+func (tree *AvlTree[T]) ceilingNode(value T) *Node[T] {
+	var found *Node[T]
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return tree.ceilingNode(value)
+			}
+			return nil
 		}
-		if value < node.value {
-			node = node.left
+		if tree.less(curr.value, value) {
+			curr = curr.children[dirRight]
 		} else {
-			node = node.right
+			found = curr
+			curr = curr.children[dirLeft]
 		}
 	}
-	return nil
+	return found
 }
 
-func (tree *AvlTree[T]) rebalance(node *Node[T]) {
-	nodeBalance := node.balanceFactor()
-	if math.Abs(float64(nodeBalance)) <= 1 {
-		node.updateHeight()
-		return
+// Returns the last node with a value less than or equal to the given value,
+// or nil if there is none
+func (tree *AvlTree[T]) floorNode(value T) *Node[T] {
+	var found *Node[T]
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return tree.floorNode(value)
+			}
+			return nil
+		}
+		if tree.less(value, curr.value) {
+			curr = curr.children[dirLeft]
+		} else {
+			found = curr
+			curr = curr.children[dirRight]
+		}
 	}
-	nodeParent := node.parent
-	var newSubtreeRoot *Node[T]
+	return found
+}
 
-	if nodeBalance < -1 {
-		if node.left.balanceFactor() > 0 {
-			node.left = node.left.rotateLeft()
-			node.left.parent = node
+// Returns the first node with a value strictly greater than the given value,
+// or nil if there is none
+func (tree *AvlTree[T]) higherNode(value T) *Node[T] {
+	var found *Node[T]
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return tree.higherNode(value)
+			}
+			return nil
 		}
-		newSubtreeRoot = node.rotateRight()
-	} else {
-		if node.right.balanceFactor() < 0 {
-			node.right = node.right.rotateRight()
-			node.right.parent = node
+		if tree.less(value, curr.value) {
+			found = curr
+			curr = curr.children[dirLeft]
+		} else {
+			curr = curr.children[dirRight]
+		}
+	}
+	return found
+}
+
+// Returns the last node with a value strictly less than the given value, or
+// nil if there is none
+func (tree *AvlTree[T]) lowerNode(value T) *Node[T] {
+	var found *Node[T]
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return tree.lowerNode(value)
+			}
+			return nil
+		}
+		if tree.less(curr.value, value) {
+			found = curr
+			curr = curr.children[dirRight]
+		} else {
+			curr = curr.children[dirLeft]
+		}
+	}
+	return found
+}
+
+// Returns the in-order successor of the node, or nil if it is the last node
+func nextNode[T any](node *Node[T]) *Node[T] {
+	return adjacentNode(node, dirRight)
+}
+
+// Returns the in-order predecessor of the node, or nil if it is the first node
+func prevNode[T any](node *Node[T]) *Node[T] {
+	return adjacentNode(node, dirLeft)
+}
+
+// Returns the node next to the given one in-order, in the given direction
+func adjacentNode[T any](node *Node[T], dir direction) *Node[T] {
+	if node.children[dir] != nil {
+		node = node.children[dir]
+		for node.children[dir.opposite()] != nil {
+			node = node.children[dir.opposite()]
 		}
-		newSubtreeRoot = node.rotateLeft()
+		return node
+	}
+	for node.parent != nil && node.parent.children[dir] == node {
+		node = node.parent
 	}
+	return node.parent
+}
+
+// Returns the leftmost node of the tree, or nil if the tree is empty
+func (tree *AvlTree[T]) minNode() *Node[T] {
+	return tree.edgeNode(dirLeft)
+}
+
+// Returns the rightmost node of the tree, or nil if the tree is empty
+func (tree *AvlTree[T]) maxNode() *Node[T] {
+	return tree.edgeNode(dirRight)
+}
+
+// Returns the last node reached by following children in the given
+// direction from the root, or nil if the tree is empty. The edge nodes are
+// cached, so this takes O(1).
+func (tree *AvlTree[T]) edgeNode(dir direction) *Node[T] {
+	return tree.edges[dir]
+}
+
+// Find the edge nodes again after the tree's nodes were replaced or relinked
+// in bulk rather than through Add and unlinkNode
+func (tree *AvlTree[T]) resetEdges() {
+	tree.edges = [2]*Node[T]{subtreeEdge(tree.root, dirLeft), subtreeEdge(tree.root, dirRight)}
+}
+
+// Returns the last node reached by following children in the given
+// direction from the node, or nil for a nil node
+func subtreeEdge[T any](node *Node[T], dir direction) *Node[T] {
+	for node != nil && node.children[dir] != nil {
+		node = node.children[dir]
+	}
+	return node
+}
+
+// Returns the first node in-order with a value equal to the given value, or
+// nil if there is none. Finding the first equal value costs one comparison
+// per level, where stopping at the first equal node met would cost two.
+func (tree *AvlTree[T]) getNodeByValue(value T) *Node[T] {
+	node := tree.ceilingNode(value)
+	if node == nil || tree.less(value, node.value) {
+		return nil
+	}
+	return node
+}
+
+// Rotate an unbalanced node to restore the balance of its subtree.
+// Returns the root of the node's subtree after rebalancing and the change in
+// the subtree's height.
+func (tree *AvlTree[T]) rebalance(node *Node[T]) (*Node[T], int) {
+	nodeParent := node.parent
+	var growth, rotated int
+
+	heavy := dirRight
+	if node.balance < 0 {
+		heavy = dirLeft
+	}
+	// A child leaning the other way is rotated first (double rotation)
+	if child := node.children[heavy]; int(child.balance)*heavy.sign() < 0 {
+		node.children[heavy], rotated = child.rotate(heavy)
+		node.children[heavy].parent = node
+		growth = node.adjustBalance(heavy, rotated)
+	}
+	newSubtreeRoot, rotated := node.rotate(heavy.opposite())
 	newSubtreeRoot.parent = nodeParent
 	tree.replaceChild(nodeParent, node, newSubtreeRoot)
+	return newSubtreeRoot, growth + rotated
+}
+
+// Build a perfectly balanced subtree from sorted values, returning its root
+func buildFromSorted[T any](values []T, parent *Node[T]) *Node[T] {
+	if len(values) == 0 {
+		return nil
+	}
+	mid := len(values) / 2
+	node := newTreeNode(values[mid])
+	node.parent = parent
+	node.children[dirLeft] = buildFromSorted(values[:mid], node)
+	node.children[dirRight] = buildFromSorted(values[mid+1:], node)
+	// A subtree of n nodes built this way has height floor(log2(n))
+	node.balance = int8(bits.Len(uint(len(values)-mid-1)) - bits.Len(uint(mid)))
+	node.update()
+	return node
+}
+
+// Returns an empty tree ordering its values with less
+func newTree[T any](less func(a, b T) bool) *AvlTree[T] {
+	return &AvlTree[T]{root: nil, height: -1, less: less}
+}
+
+// Returns an empty tree with the tree's order and settings: its misuse
+// policy, height guard and self-healing, and a negative lookup filter if the
+// tree keeps one
+func (tree *AvlTree[T]) emptyLike() *AvlTree[T] {
+	like := newTree(tree.less)
+	like.misusePolicy = tree.misusePolicy
+	like.heightGuard = tree.heightGuard
+	like.onHeal = tree.onHeal
+	like.clone = tree.clone
+	like.rejectNil = tree.rejectNil
+	if tree.filter != nil {
+		like.filter = &negativeFilter[T]{hash: tree.filter.hash}
+		like.filter.reset(0)
+	}
+	return like
+}
+
+// Returns true if neither value sorts before the other in the tree's order
+func (tree *AvlTree[T]) equal(a, b T) bool {
+	return !tree.less(a, b) && !tree.less(b, a)
+}
+
+// Returns -1, 0 or 1 as a sorts before, equal to or after b in the tree's
+// order, like cmp.Compare
+func (tree *AvlTree[T]) compare(a, b T) int {
+	switch {
+	case tree.less(a, b):
+		return -1
+	case tree.less(b, a):
+		return 1
+	}
+	return 0
+}
+
+// Returns true if the node is part of this tree
+func (tree *AvlTree[T]) ownsNode(node *Node[T]) bool {
+	for node != nil && node.parent != nil {
+		node = node.parent
+	}
+	return node != nil && node == tree.root
 }
 
 func (tree *AvlTree[T]) getRootNode() *Node[T] {
@@ -393,9 +1183,5 @@ func (tree *AvlTree[T]) replaceChild(parent *Node[T], child *Node[T], replacemen
 		return
 	}
 
-	if parent.left == child {
-		parent.left = replacement
-	} else {
-		parent.right = replacement
-	}
+	parent.children[parent.sideOf(child)] = replacement
 }
@@ -1,7 +1,10 @@
 package avl
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"slices"
 	"testing"
 )
@@ -26,6 +29,55 @@ func assertSlice[T comparable](a, b []T, msg string, t *testing.T) {
 	}
 }
 
+// Check balance factors, subtree sizes and parent links of every node in the
+// tree
+func assertBalanced[T any](tree *AvlTree[T], msg string, t *testing.T) {
+	t.Helper()
+	var check func(node, parent *Node[T]) int
+	check = func(node, parent *Node[T]) int {
+		if node == nil {
+			return -1
+		}
+		if node.parent != parent {
+			t.Errorf("%s: node %v has wrong parent", msg, node.value)
+		}
+		leftHeight := check(node.children[dirLeft], node)
+		rightHeight := check(node.children[dirRight], node)
+		height := max(leftHeight, rightHeight) + 1
+		if balance := rightHeight - leftHeight; node.balanceFactor() != balance {
+			t.Errorf("%s: node %v has balance %+d, expected %+d", msg, node.value, node.balanceFactor(), balance)
+		}
+		if count := nodeCount(node.children[dirLeft]) + nodeCount(node.children[dirRight]) + 1; node.count != count {
+			t.Errorf("%s: node %v has count %d, expected %d", msg, node.value, node.count, count)
+		}
+		if rightHeight-leftHeight > 1 || leftHeight-rightHeight > 1 {
+			t.Errorf("%s: node %v is unbalanced", msg, node.value)
+		}
+		return height
+	}
+	if height := check(tree.root, nil); tree.Height() != height {
+		t.Errorf("%s: tree height %d, measured %d", msg, tree.Height(), height)
+	}
+	if nodeCount(tree.root) != tree.size {
+		t.Errorf("%s: root count %d != size %d", msg, nodeCount(tree.root), tree.size)
+	}
+	edges := tree.edges
+	if tree.resetEdges(); tree.edges != edges {
+		t.Errorf("%s: cached edge nodes are stale", msg)
+	}
+}
+
+// Returns the shape of the subtree in pre-order as (value left right)
+func shapeString[T any](node *Node[T]) string {
+	if node == nil {
+		return "-"
+	}
+	if node.children[dirLeft] == nil && node.children[dirRight] == nil {
+		return fmt.Sprint(node.value)
+	}
+	return fmt.Sprintf("(%v %s %s)", node.value, shapeString(node.children[dirLeft]), shapeString(node.children[dirRight]))
+}
+
 var cases = [][]int{
 	{},                    // Empty tree
 	{1, 2, 3},             // Right-Right case
@@ -93,12 +145,12 @@ func TestInsertNode(t *testing.T) {
 		root := tree.getRootNode()
 
 		assert(root.value, sample.root, "insertNode (root)", t)
-		assert(root.left.value, sample.lsub, "insertNode(root.left)", t)
-		assert(root.right.value, sample.rsub, "insertNode(root.right)", t)
-		assert(root.left.left.value, sample.lsubl, "insertNode(root.left.left)", t)
-		assert(root.left.right.value, sample.lsubr, "insertNode(root.left.right)", t)
-		assert(root.right.left.value, sample.rsubl, "insertNode(root.right.left)", t)
-		assert(root.right.right.value, sample.rsubr, "insertNode(root.right.right)", t)
+		assert(root.children[dirLeft].value, sample.lsub, "insertNode(root.children[dirLeft])", t)
+		assert(root.children[dirRight].value, sample.rsub, "insertNode(root.children[dirRight])", t)
+		assert(root.children[dirLeft].children[dirLeft].value, sample.lsubl, "insertNode(root.children[dirLeft].children[dirLeft])", t)
+		assert(root.children[dirLeft].children[dirRight].value, sample.lsubr, "insertNode(root.children[dirLeft].children[dirRight])", t)
+		assert(root.children[dirRight].children[dirLeft].value, sample.rsubl, "insertNode(root.children[dirRight].children[dirLeft])", t)
+		assert(root.children[dirRight].children[dirRight].value, sample.rsubr, "insertNode(root.children[dirRight].children[dirRight])", t)
 
 	}
 }
@@ -136,6 +188,7 @@ func TestIntegerTree(t *testing.T) {
 		expected := slices.Clone(testCase)
 		slices.Sort(expected)
 		assertSlice(actual, expected, "tree.Add(...)", t)
+		assertBalanced(tree, "tree.Add(...)", t)
 	}
 }
 
@@ -211,6 +264,7 @@ func TestRemoveValues(t *testing.T) {
 			expectedValues := slices.Clone(actualValues)
 			slices.Sort(expectedValues)
 			assertSlice(actualValues, expectedValues, "tree.Remove(v)", t)
+			assertBalanced(tree, "tree.Remove(v)", t)
 
 		}
 	}
@@ -245,6 +299,48 @@ func TestClearTree(t *testing.T) {
 	assert(tree.Size(), 0, "tree.size after Remove", t)
 }
 
+// Test that scrubbing unlinks every node before clearing the tree
+func TestClearAndScrub(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(1, 50, 1))
+	root := tree.getRootNode()
+	leftmost := tree.minNode()
+	tree.ClearAndScrub()
+	assert(tree.IsEmpty(), true, "tree.ClearAndScrub()", t)
+	assert(tree.Size(), 0, "tree.size after ClearAndScrub", t)
+	assert(root.children[dirLeft] == nil && root.children[dirRight] == nil, true, "root children after ClearAndScrub", t)
+	assert(leftmost.parent == nil, true, "leaf parent after ClearAndScrub", t)
+
+	tree.Add(1)
+	assertSlice(tree.InOrderTraverse(), []int{1}, "tree.Add after ClearAndScrub", t)
+}
+
+// Test the container-convention aliases and String
+func TestLenMinMaxString(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		assert(tree.Len(), tree.Size(), "tree.Len()", t)
+		minVal, minErr := tree.Min()
+		getMinVal, getMinErr := tree.GetMin()
+		assert(minVal, getMinVal, "tree.Min()", t)
+		assert(minErr == nil, getMinErr == nil, "tree.Min() error", t)
+		maxVal, maxErr := tree.Max()
+		getMaxVal, getMaxErr := tree.GetMax()
+		assert(maxVal, getMaxVal, "tree.Max()", t)
+		assert(maxErr == nil, getMaxErr == nil, "tree.Max() error", t)
+		assert(tree.String(), fmt.Sprint(tree.InOrderTraverse()), "tree.String()", t)
+	}
+	assert(populateTree(t, []int{3, 1, 2}).String(), "[1 2 3]", "tree.String()", t)
+}
+
+// Test node descriptions for the root and both kinds of children
+func TestNodeString(t *testing.T) {
+	tree := populateTree(t, []int{8, 5, 9, 6})
+	assert(tree.FindNode(8).String(), "8 (h=2 bf=-1 root)", "root.String()", t)
+	assert(tree.FindNode(5).String(), "5 (h=1 bf=+1 left of 8)", "left.String()", t)
+	assert(tree.FindNode(6).String(), "6 (h=0 bf=+0 right of 5)", "right.String()", t)
+	assert(fmt.Sprint(tree.FindNode(7)), "<nil>", "nil.String()", t)
+}
+
 func TestGetMinNode(t *testing.T) {
 	for _, testCase := range cases {
 		tree := populateTree(t, testCase)
@@ -302,3 +398,399 @@ func TestAvlTreeIterator(t *testing.T) {
 
 	}
 }
+
+// Test that Reset restarts the iterator from the tree's current state
+// without growing its stack
+func TestAvlTreeIteratorReset(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(1, 100, 1))
+	iter := tree.NewIterator()
+	assert(cap(iter.stack), tree.Height()+1, "iterator stack capacity", t)
+
+	for i := 0; i < 10; i++ {
+		iter.Next()
+	}
+	tree.Remove(1)
+	iter.Reset()
+	v, index := iter.Next()
+	assert(v, 2, "iterator.Next() after Reset", t)
+	assert(index, 0, "iterator index after Reset", t)
+
+	stackCap := cap(iter.stack)
+	for _, index = iter.Next(); index != -1; _, index = iter.Next() {
+	}
+	iter.Reset()
+	assert(cap(iter.stack), stackCap, "iterator stack capacity after Reset", t)
+
+	empty := NewAvlTree[int]()
+	_, index = empty.NewIterator().Next()
+	assert(index, -1, "iterator.Next() on empty tree", t)
+}
+
+// Test that an iterator stops at the first modification of its tree and
+// reports it with Err, for every kind of mutation, and that reads don't count
+func TestAvlTreeIteratorFailFast(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(1, 20, 1))
+	mutations := map[string]func(){
+		"Add":          func() { tree.Add(21) },
+		"Remove":       func() { tree.Remove(5) },
+		"PopMin":       func() { tree.PopMin() },
+		"RemoveRange":  func() { tree.RemoveRange(3, 6, nil) },
+		"UpdateValue":  func() { tree.UpdateValue(tree.FindNode(10), 10) },
+		"RotateLeftAt": func() { tree.RotateLeftAt(tree.root) },
+		"Clear":        func() { tree.Clear() },
+	}
+	for name, mutate := range mutations {
+		tree = populateTree(t, rangeWithSteps(1, 20, 1))
+		iter := tree.NewIterator()
+		iter.Next()
+		iter.Next()
+		assert(iter.Err(), nil, name+": iter.Err() before modification", t)
+
+		mutate()
+		_, index := iter.Next()
+		assert(index, -1, name+": iter.Next() after modification", t)
+		assert(iter.Err(), ErrConcurrentModification, name+": iter.Err() after modification", t)
+
+		iter.Reset()
+		assert(iter.Err(), nil, name+": iter.Err() after Reset", t)
+		if _, index = iter.Next(); tree.Len() > 0 {
+			assert(index, 0, name+": iter.Next() after Reset", t)
+		}
+	}
+
+	// Reads and running off the end are not modifications
+	tree = populateTree(t, rangeWithSteps(1, 20, 1))
+	iter := tree.NewIterator()
+	iter.Next()
+	tree.Contains(3)
+	tree.CountLess(10)
+	tree.InOrderTraverse()
+	_, index := iter.Next()
+	assert(index, 1, "iter.Next() after reads", t)
+	for ; index != -1; _, index = iter.Next() {
+	}
+	assert(iter.Err(), nil, "iter.Err() after reaching the end", t)
+}
+
+// Test that equal values are traversed in insertion order. Positive and
+// negative zero compare equal but can be told apart by their sign bit.
+func TestEqualValuesInsertionOrder(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	tree := NewAvlTree[float64]()
+	signs := []bool{false, true, true, false, true, false, false, true}
+	for i, neg := range signs {
+		if neg {
+			tree.Add(negZero)
+		} else {
+			tree.Add(0)
+		}
+		// Surrounding values force rotations around the equal values
+		tree.Add(float64(i + 1))
+		tree.Add(-float64(i + 1))
+	}
+
+	actual := make([]bool, 0)
+	for _, v := range tree.InOrderTraverse() {
+		if v == 0 {
+			actual = append(actual, math.Signbit(v))
+		}
+	}
+	assertSlice(actual, signs, "insertion order of equal values", t)
+}
+
+// Test removing the extremes of the tree directly
+func TestPopMinMax(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		expected := slices.Clone(testCase)
+		slices.Sort(expected)
+		for len(expected) > 0 {
+			minVal, _ := tree.PopMin()
+			assert(minVal, expected[0], "tree.PopMin()", t)
+			expected = expected[1:]
+			if len(expected) == 0 {
+				break
+			}
+			maxVal, _ := tree.PopMax()
+			assert(maxVal, expected[len(expected)-1], "tree.PopMax()", t)
+			expected = expected[:len(expected)-1]
+			assertSlice(tree.InOrderTraverse(), expected, "tree after PopMin/PopMax", t)
+		}
+		_, err := tree.PopMin()
+		assert(err != nil, true, "tree.PopMin() on empty tree", t)
+		_, err = tree.PopMax()
+		assert(err != nil, true, "tree.PopMax() on empty tree", t)
+	}
+}
+
+// Test that the cached minimum and maximum follow every kind of mutation
+func TestMinMaxTracking(t *testing.T) {
+	tree := NewAvlTree[int]()
+	check := func(min, max int, msg string) {
+		t.Helper()
+		assertBalanced(tree, msg, t)
+		actualMin, _ := tree.GetMin()
+		actualMax, _ := tree.GetMax()
+		assert(actualMin, min, msg+" min", t)
+		assert(actualMax, max, msg+" max", t)
+	}
+	for _, v := range []int{50, 30, 70, 20, 80, 10, 90} {
+		tree.Add(v)
+	}
+	check(10, 90, "after Add")
+	tree.PopMin()
+	tree.PopMax()
+	check(20, 80, "after PopMin and PopMax")
+	tree.Remove(20)
+	tree.Remove(80)
+	check(30, 70, "after Remove")
+	tree.AppendMax(75)
+	check(30, 75, "after AppendMax")
+	tree.DrainMin(1)
+	tree.RemoveRange(72, 80, nil)
+	check(50, 70, "after DrainMin and RemoveRange")
+	other := populateTree(t, []int{5, 100})
+	tree.Graft(other)
+	check(5, 100, "after Graft")
+	tree.Clear()
+	_, err := tree.GetMin()
+	assert(err, ErrEmptyTree, "tree.GetMin() after Clear", t)
+}
+
+// Test that the same operation sequence always produces the same shape
+func TestDeterministicShape(t *testing.T) {
+	build := func() *AvlTree[int] {
+		tree := populateTree(t, []int{50, 40, 60, 30, 70, 20, 80, 45, 10, 5})
+		tree.Remove(40)
+		tree.Remove(70)
+		tree.Add(42)
+		return tree
+	}
+	first, second := build(), build()
+	assert(shapeString(first.root), shapeString(second.root), "shape of identical sequences", t)
+}
+
+// Test that canonical forms depend only on the tree's values
+func TestCanonicalForm(t *testing.T) {
+	ascending := populateTree(t, rangeWithSteps(1, 10, 1))
+	descending := populateTree(t, []int{10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	assert(shapeString(ascending.root) != shapeString(descending.root), true, "shapes of different sequences", t)
+
+	canonical := ascending.CanonicalForm()
+	assert(shapeString(canonical.root), shapeString(descending.CanonicalForm().root), "canonical shapes", t)
+	assertSlice(canonical.InOrderTraverse(), ascending.InOrderTraverse(), "canonical values", t)
+	assertBalanced(canonical, "canonical tree", t)
+
+	// Golden shape: the canonical layout is part of the API
+	assert(shapeString(canonical.root), "(6 (3 (2 1 -) (5 4 -)) (9 (8 7 -) 10))", "canonical golden shape", t)
+}
+
+// Test the tree height computed from balance factors
+func TestHeight(t *testing.T) {
+	tree := NewAvlTree[int]()
+	assert(tree.Height(), -1, "empty tree.Height()", t)
+	tree.Add(1)
+	assert(tree.Height(), 0, "single node tree.Height()", t)
+	for v := 2; v < 1024; v++ {
+		tree.Add(v)
+	}
+	assert(tree.Height(), 9, "tree.Height() of 1023 ascending values", t)
+	for v := 1; v < 1024; v += 2 {
+		tree.Remove(v)
+	}
+	assertBalanced(tree, "tree after removals", t)
+	assert(tree.Height() <= 9, true, "tree.Height() after removals", t)
+}
+
+// Test that every mutation bumps the version and reads don't
+func TestVersion(t *testing.T) {
+	tree := NewAvlTree[int]()
+	assert(tree.Version(), uint64(0), "new tree.Version()", t)
+	tree.Add(1)
+	tree.Add(2)
+	assert(tree.Version(), uint64(2), "tree.Version() after Add", t)
+	tree.Contains(1)
+	tree.InOrderTraverse()
+	assert(tree.Version(), uint64(2), "tree.Version() after reads", t)
+	tree.Remove(1)
+	tree.Clear()
+	assert(tree.Version(), uint64(4), "tree.Version() after Remove and Clear", t)
+	assert(tree.Height(), -1, "tree.Height() after Clear", t)
+}
+
+// Test that appending builds the same tree as adding the same values, and
+// that a value below the maximum is refused
+func TestAppendMax(t *testing.T) {
+	tree, added := NewAvlTree[int](), NewAvlTree[int]()
+	for _, v := range []int{1, 2, 2, 3, 5, 8, 8, 8, 13, 21, 34, 55} {
+		assert(tree.AppendMax(v), nil, fmt.Sprintf("tree.AppendMax(%d)", v), t)
+		added.Add(v)
+		assertBalanced(tree, fmt.Sprintf("tree after AppendMax(%d)", v), t)
+	}
+	assert(shapeString(tree.root), shapeString(added.root), "shape after AppendMax", t)
+
+	err := tree.AppendMax(54)
+	assert(errors.Is(err, ErrValueOutOfRange), true, "tree.AppendMax() below the maximum", t)
+	assert(tree.Len(), 12, "tree.Len() after refused AppendMax", t)
+	assert(tree.Version(), uint64(12), "tree.Version() after refused AppendMax", t)
+}
+
+type record struct {
+	time int
+	name string
+}
+
+// Test a tree of structs ordered by a comparator, with records sharing a
+// timestamp kept in insertion order
+func TestNewAvlTreeFunc(t *testing.T) {
+	tree := NewAvlTreeFunc(func(a, b record) bool { return a.time < b.time })
+	for _, r := range []record{{3, "c"}, {1, "a"}, {2, "b1"}, {5, "e"}, {2, "b2"}, {4, "d"}} {
+		tree.Add(r)
+	}
+	assertBalanced(tree, "comparator tree", t)
+	assertSlice(tree.InOrderTraverse(), []record{{1, "a"}, {2, "b1"}, {2, "b2"}, {3, "c"}, {4, "d"}, {5, "e"}}, "comparator tree values", t)
+
+	// Lookups match on the comparator alone
+	assert(tree.Contains(record{time: 4}), true, "tree.Contains(time 4)", t)
+	assert(tree.Contains(record{time: 6}), false, "tree.Contains(time 6)", t)
+	assert(tree.FindNode(record{time: 2}).Value(), record{2, "b1"}, "tree.FindNode(time 2)", t)
+	assert(tree.Remove(record{time: 2}), true, "tree.Remove(time 2)", t)
+	assert(tree.FindNode(record{time: 2}).Value(), record{2, "b2"}, "tree.FindNode(time 2) after Remove", t)
+
+	floor, ceiling, _, _ := tree.FloorCeilingPair(record{time: 0})
+	assert(floor, record{}, "floor of time 0", t)
+	assert(ceiling, record{1, "a"}, "ceiling of time 0", t)
+	assert(tree.CountLess(record{time: 4}), 3, "tree.CountLess(time 4)", t)
+	assert(tree.Freeze().Contains(record{time: 5}), true, "frozen.Contains(time 5)", t)
+
+	// Trees derived from it keep its order
+	canonical := tree.CanonicalForm()
+	canonical.Add(record{0, "z"})
+	assert(canonical.FindNode(record{time: 0}).Value(), record{0, "z"}, "canonical.FindNode(time 0)", t)
+	other := NewAvlTreeFunc(func(a, b record) bool { return a.time < b.time })
+	other.Add(record{2, "b3"})
+	assert(tree.Graft(other), nil, "tree.Graft()", t)
+	assertSlice(tree.InOrderTraverse(), []record{{1, "a"}, {2, "b2"}, {2, "b3"}, {3, "c"}, {4, "d"}, {5, "e"}}, "values after Graft", t)
+	assertBalanced(tree, "tree after Graft", t)
+}
+
+func (r record) Less(other record) bool {
+	return r.time < other.time
+}
+
+func TestNewAvlTreeOrderedBy(t *testing.T) {
+	tree := NewAvlTreeOrderedBy[record]()
+	for _, r := range []record{{3, "c"}, {1, "a"}, {2, "b1"}, {2, "b2"}} {
+		tree.Add(r)
+	}
+	assertBalanced(tree, "Lesser tree", t)
+	assertSlice(tree.InOrderTraverse(), []record{{1, "a"}, {2, "b1"}, {2, "b2"}, {3, "c"}}, "Lesser tree values", t)
+	assert(tree.Contains(record{time: 3}), true, "tree.Contains(time 3)", t)
+}
+
+// Test that a tree built from a sorted slice is balanced, holds the values
+// and behaves like one built by Add
+func TestNewFromSortedSlice(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 7, 100} {
+		values := rangeWithSteps(1, n, 1)
+		tree, err := NewFromSortedSlice(values, WithNegativeLookupFilter())
+		msg := fmt.Sprintf("NewFromSortedSlice() of %d values", n)
+		assert(err, nil, msg+" error", t)
+		assertSlice(tree.InOrderTraverse(), values, msg, t)
+		assertBalanced(tree, msg, t)
+		for _, v := range values {
+			assert(tree.Contains(v), true, fmt.Sprintf("%s Contains(%d)", msg, v), t)
+		}
+		assert(tree.Contains(n+1), false, msg+" Contains() of a missing value", t)
+	}
+
+	tree, _ := NewFromSortedSlice([]int{1, 2, 2, 3})
+	tree.Add(2)
+	tree.Remove(1)
+	assertSlice(tree.InOrderTraverse(), []int{2, 2, 2, 3}, "tree built from a slice after Add and Remove", t)
+	assertBalanced(tree, "tree built from a slice after Add and Remove", t)
+
+	_, err := NewFromSortedSlice([]int{1, 3, 2})
+	assert(errors.Is(err, ErrInvalidArgument), true, "NewFromSortedSlice() of unsorted values", t)
+}
+
+// Test building trees from unsorted slices, with and without duplicates
+func TestNewFromSlice(t *testing.T) {
+	for _, testCase := range cases {
+		expected := slices.Clone(testCase)
+		slices.Sort(expected)
+		tree := NewFromSlice(testCase)
+		assertSlice(tree.InOrderTraverse(), expected, "NewFromSlice()", t)
+		assertBalanced(tree, "NewFromSlice()", t)
+
+		unique := NewFromSliceUnique(testCase)
+		assertSlice(unique.InOrderTraverse(), slices.Compact(expected), "NewFromSliceUnique()", t)
+		assertBalanced(unique, "NewFromSliceUnique()", t)
+	}
+
+	values := []int{3, 1, 3, 2}
+	NewFromSlice(values)
+	assertSlice(values, []int{3, 1, 3, 2}, "slice after NewFromSlice()", t)
+}
+
+// Test updating values in place, within and outside of their node's position
+func TestUpdateValue(t *testing.T) {
+	tree := NewAvlTreeFunc(func(a, b record) bool { return a.time < b.time })
+	for _, r := range []record{{1, "a"}, {3, "c"}, {5, "e"}, {3, "c2"}} {
+		tree.Add(r)
+	}
+	version := tree.Version()
+	node := tree.FindNode(record{time: 3})
+	assert(tree.UpdateValue(node, record{3, "updated"}), nil, "tree.UpdateValue() keeping the key", t)
+	assert(tree.Version(), version+1, "tree.Version() after UpdateValue", t)
+	assert(tree.UpdateValue(tree.FindNode(record{time: 5}), record{4, "e"}), nil, "tree.UpdateValue() within the gap", t)
+	assertSlice(tree.InOrderTraverse(), []record{{1, "a"}, {3, "updated"}, {3, "c2"}, {4, "e"}}, "tree after UpdateValue", t)
+
+	err := tree.UpdateValue(node, record{0, "x"})
+	assert(errors.Is(err, ErrValueOutOfRange), true, "tree.UpdateValue() before the previous value", t)
+	err = tree.UpdateValue(tree.FindNode(record{time: 1}), record{4, "x"})
+	assert(errors.Is(err, ErrValueOutOfRange), true, "tree.UpdateValue() past the next value", t)
+	assert(node.Value(), record{3, "updated"}, "node after a rejected update", t)
+
+	other := NewAvlTreeFunc(func(a, b record) bool { return a.time < b.time })
+	other.Add(record{3, "c"})
+	err = tree.UpdateValue(other.FindNode(record{time: 3}), record{3, "x"})
+	assert(errors.Is(err, ErrInvalidNode), true, "tree.UpdateValue() of another tree's node", t)
+	assert(errors.Is(tree.UpdateValue(nil, record{}), ErrInvalidNode), true, "tree.UpdateValue(nil)", t)
+}
+
+func BenchmarkNewFromSortedSlice(b *testing.B) {
+	values := rangeWithSteps(0, 9999, 1)
+	for i := 0; i < b.N; i++ {
+		NewFromSortedSlice(values)
+	}
+}
+
+func BenchmarkAddAscending(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		tree := NewAvlTree[int]()
+		for v := 0; v < 10000; v++ {
+			tree.Add(v)
+		}
+	}
+}
+
+func BenchmarkAddRandom(b *testing.B) {
+	values := rand.New(rand.NewSource(1)).Perm(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree := NewAvlTree[int]()
+		for _, v := range values {
+			tree.Add(v)
+		}
+	}
+}
+
+func BenchmarkAppendMax(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		tree := NewAvlTree[int]()
+		for v := 0; v < 10000; v++ {
+			tree.AppendMax(v)
+		}
+	}
+}
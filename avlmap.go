@@ -0,0 +1,235 @@
+package avl
+
+import (
+	"fmt"
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// AvlMap is an ordered map: key/value pairs kept in an AvlTree ordered by
+// key, so that entries can be looked up by key and iterated in key order
+// without a separate map alongside the tree. Each key is held at most once.
+type AvlMap[K any, V any] struct {
+	tree *AvlTree[mapEntry[K, V]]
+	// Called with entries released for the last time, see Release
+	finalize func(K, V)
+}
+
+type mapEntry[K any, V any] struct {
+	key   K
+	value V
+	// References held on the entry besides the one taken by Put
+	refs int
+}
+
+// %% Public methods %%
+
+// Returns a new, empty map ordered by key with <
+func NewAvlMap[K constraints.Ordered, V any]() *AvlMap[K, V] {
+	return NewAvlMapFunc[K, V](func(a, b K) bool { return a < b })
+}
+
+// Returns a new, empty map ordered by key with the given less function, for
+// key types that are not constraints.Ordered. Keys a and b are the same key
+// when neither is less than the other.
+func NewAvlMapFunc[K any, V any](less func(a, b K) bool) *AvlMap[K, V] {
+	return &AvlMap[K, V]{tree: NewAvlTreeFunc(func(a, b mapEntry[K, V]) bool { return less(a.key, b.key) })}
+}
+
+// Set the value for the key, replacing any value it already had. A new entry
+// starts with one reference, see AddRef; replacing a value keeps the count.
+func (m *AvlMap[K, V]) Put(key K, value V) {
+	if node := m.tree.getNodeByValue(mapEntry[K, V]{key: key}); node != nil {
+		node.value.value = value
+		return
+	}
+	m.tree.Add(mapEntry[K, V]{key: key, value: value})
+}
+
+// Returns the value for the key, or the zero value and false if the key is
+// not in the map
+func (m *AvlMap[K, V]) Get(key K) (V, bool) {
+	node := m.tree.getNodeByValue(mapEntry[K, V]{key: key})
+	if node == nil {
+		var zero V
+		return zero, false
+	}
+	return node.value.value, true
+}
+
+// Returns the value for the key. Panics with an error wrapping ErrNotFound if
+// the key is not in the map, for keys the caller knows are there.
+func (m *AvlMap[K, V]) MustGet(key K) V {
+	value, ok := m.Get(key)
+	if !ok {
+		panic(fmt.Errorf("key %v is not in the map: %w", key, ErrNotFound))
+	}
+	return value
+}
+
+// Returns the value for the key, or fallback if the key is not in the map
+func (m *AvlMap[K, V]) GetOrDefault(key K, fallback V) V {
+	if value, ok := m.Get(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// Returns a bool indicating whether the key is in the map
+func (m *AvlMap[K, V]) Contains(key K) bool {
+	return m.tree.Contains(mapEntry[K, V]{key: key})
+}
+
+// Remove the key and its value from the map.
+// Returns true on successful removal, false if the key was not found.
+func (m *AvlMap[K, V]) Delete(key K) bool {
+	return m.tree.Remove(mapEntry[K, V]{key: key})
+}
+
+// Return the number of entries in the map
+func (m *AvlMap[K, V]) Len() int {
+	return m.tree.Len()
+}
+
+// Remove every entry from the map
+func (m *AvlMap[K, V]) Clear() {
+	m.tree.Clear()
+}
+
+// Take another reference on the entry for the key, for maps holding shared
+// resources: the entry stays in the map until every reference, including the
+// one taken by Put, is given up with Release.
+// Returns an error if the key is not in the map.
+func (m *AvlMap[K, V]) AddRef(key K) error {
+	node := m.tree.getNodeByValue(mapEntry[K, V]{key: key})
+	if node == nil {
+		return m.tree.misuse(fmt.Errorf("key %v is not in the map: %w", key, ErrNotFound))
+	}
+	node.value.refs += 1
+	return nil
+}
+
+// Give up a reference on the entry for the key. Releasing the last reference
+// removes the entry and passes it to the function set with OnFinalize.
+// Returns a bool indicating whether the entry was removed, or an error if the
+// key is not in the map, e.g. because it was already released.
+func (m *AvlMap[K, V]) Release(key K) (bool, error) {
+	node := m.tree.getNodeByValue(mapEntry[K, V]{key: key})
+	if node == nil {
+		return false, m.tree.misuse(fmt.Errorf("key %v is not in the map: %w", key, ErrNotFound))
+	}
+	if node.value.refs > 0 {
+		node.value.refs -= 1
+		return false, nil
+	}
+	entry := node.value
+	m.tree.removeNode(node)
+	if m.finalize != nil {
+		m.finalize(entry.key, entry.value)
+	}
+	return true, nil
+}
+
+// Returns the number of references held on the entry for the key, or 0 if
+// the key is not in the map
+func (m *AvlMap[K, V]) RefCount(key K) int {
+	node := m.tree.getNodeByValue(mapEntry[K, V]{key: key})
+	if node == nil {
+		return 0
+	}
+	return node.value.refs + 1
+}
+
+// Set a function to call with each entry removed by releasing its last
+// reference, e.g. to close the resource it holds. Entries removed with Delete
+// or Clear are not passed to it. A nil function clears it.
+func (m *AvlMap[K, V]) OnFinalize(finalize func(K, V)) {
+	m.finalize = finalize
+}
+
+// Set how the map reports misuse, such as releasing a key that is not in the
+// map. See MisusePolicy.
+func (m *AvlMap[K, V]) SetMisusePolicy(policy MisusePolicy) {
+	m.tree.SetMisusePolicy(policy)
+}
+
+// Returns a slice of the map's keys in ascending order
+func (m *AvlMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.tree.Len())
+	for key := range m.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Returns a slice of the map's values in ascending order of their keys
+func (m *AvlMap[K, V]) Values() []V {
+	values := make([]V, 0, m.tree.Len())
+	for _, value := range m.All() {
+		values = append(values, value)
+	}
+	return values
+}
+
+// Returns an iterator over the map's entries in ascending order of key
+func (m *AvlMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for node := m.tree.minNode(); node != nil; node = nextNode(node) {
+			if !yield(node.value.key, node.value.value) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over the entries with keys greater than or equal to
+// from and less than to, in ascending order of key
+func (m *AvlMap[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		end := mapEntry[K, V]{key: to}
+		node := m.tree.ceilingNode(mapEntry[K, V]{key: from})
+		for ; node != nil && m.tree.less(node.value, end); node = nextNode(node) {
+			if !yield(node.value.key, node.value.value) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the rank of the key: the number of keys in the map less than it,
+// which is its index in key order if it is held, found in O(log n). Also
+// returns a bool indicating whether the key is in the map.
+func (m *AvlMap[K, V]) RankOfKey(key K) (int, bool) {
+	return m.tree.Rank(mapEntry[K, V]{key: key})
+}
+
+// Returns the key at the given index in key order, counting from 0, in
+// O(log n). Returns an error wrapping ErrValueOutOfRange if the index is
+// negative or not less than Len.
+func (m *AvlMap[K, V]) KeyAt(index int) (K, error) {
+	key, _, err := m.EntryAt(index)
+	return key, err
+}
+
+// Returns the key and value of the entry at the given index in key order, as
+// KeyAt does.
+func (m *AvlMap[K, V]) EntryAt(index int) (K, V, error) {
+	entry, err := m.tree.At(index)
+	return entry.key, entry.value, err
+}
+
+// Returns an iterator over the map's entries starting from the given key:
+// ascending from the first key greater than it, or descending from the last
+// key less than it. With inclusive set, the key itself is included if held.
+// The map must not be modified during iteration.
+func (m *AvlMap[K, V]) IterateFrom(key K, inclusive, descending bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		node, step := m.tree.startFrom(mapEntry[K, V]{key: key}, inclusive, descending)
+		for ; node != nil; node = step(node) {
+			if !yield(node.value.key, node.value.value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package avl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Test putting, replacing, getting and deleting entries
+func TestAvlMap(t *testing.T) {
+	m := NewAvlMap[string, int]()
+	for i, key := range []string{"tahini", "za'atar", "chickpeas", "lemon"} {
+		m.Put(key, i)
+	}
+	m.Put("tahini", 10)
+	assert(m.Len(), 4, "m.Len()", t)
+
+	value, ok := m.Get("tahini")
+	assert(ok, true, "m.Get(\"tahini\") ok", t)
+	assert(value, 10, "m.Get(\"tahini\") after replacing it", t)
+	_, ok = m.Get("garlic")
+	assert(ok, false, "m.Get(\"garlic\") ok", t)
+	assert(m.Contains("lemon"), true, "m.Contains(\"lemon\")", t)
+
+	assertSlice(m.Keys(), []string{"chickpeas", "lemon", "tahini", "za'atar"}, "m.Keys()", t)
+	assertSlice(m.Values(), []int{2, 3, 10, 1}, "m.Values()", t)
+
+	assert(m.Delete("lemon"), true, "m.Delete(\"lemon\")", t)
+	assert(m.Delete("lemon"), false, "m.Delete(\"lemon\") again", t)
+	assertSlice(m.Keys(), []string{"chickpeas", "tahini", "za'atar"}, "m.Keys() after Delete", t)
+
+	m.Clear()
+	assert(m.Len(), 0, "m.Len() after Clear", t)
+}
+
+// Test iterating over all entries and over a range of keys
+func TestAvlMapIteration(t *testing.T) {
+	m := NewAvlMap[int, string]()
+	for k := 10; k > 0; k-- {
+		m.Put(k, fmt.Sprint("v", k))
+	}
+	entries := make([]string, 0)
+	for k, v := range m.All() {
+		if k > 3 {
+			break
+		}
+		entries = append(entries, fmt.Sprint(k, "=", v))
+	}
+	assertSlice(entries, []string{"1=v1", "2=v2", "3=v3"}, "m.All() with break", t)
+
+	entries = entries[:0]
+	for k, v := range m.Range(4, 7) {
+		entries = append(entries, fmt.Sprint(k, "=", v))
+	}
+	assertSlice(entries, []string{"4=v4", "5=v5", "6=v6"}, "m.Range(4, 7)", t)
+}
+
+// Test that an entry is removed and finalized when its last reference is
+// released, and that releasing a missing key is reported
+func TestAvlMapRefCount(t *testing.T) {
+	m := NewAvlMap[string, int]()
+	finalized := make([]string, 0)
+	m.OnFinalize(func(key string, value int) {
+		finalized = append(finalized, fmt.Sprint(key, "=", value))
+	})
+	m.Put("conn", 1)
+	m.Put("file", 2)
+	assert(m.RefCount("conn"), 1, "m.RefCount(\"conn\") after Put", t)
+	assert(m.AddRef("conn"), nil, "m.AddRef(\"conn\")", t)
+	m.Put("conn", 3)
+	assert(m.RefCount("conn"), 2, "m.RefCount(\"conn\") after AddRef and Put", t)
+
+	removed, err := m.Release("conn")
+	assert(removed, false, "m.Release(\"conn\") with a reference left", t)
+	assert(err, nil, "m.Release(\"conn\") error", t)
+	removed, _ = m.Release("conn")
+	assert(removed, true, "m.Release(\"conn\") of the last reference", t)
+	assert(m.Contains("conn"), false, "m.Contains(\"conn\") after Release", t)
+	assert(m.RefCount("conn"), 0, "m.RefCount(\"conn\") after Release", t)
+
+	_, err = m.Release("conn")
+	assert(errors.Is(err, ErrNotFound), true, "m.Release(\"conn\") again", t)
+	assert(errors.Is(m.AddRef("conn"), ErrNotFound), true, "m.AddRef(\"conn\") after Release", t)
+
+	m.Delete("file")
+	assertSlice(finalized, []string{"conn=3"}, "finalized entries", t)
+}
+
+// Test a map ordered by a less function over keys that aren't ordered by <
+func TestAvlMapFunc(t *testing.T) {
+	m := NewAvlMapFunc[[2]int, string](func(a, b [2]int) bool {
+		return a[0] < b[0] || a[0] == b[0] && a[1] < b[1]
+	})
+	m.Put([2]int{2, 1}, "c")
+	m.Put([2]int{1, 5}, "b")
+	m.Put([2]int{1, 2}, "a")
+	m.Put([2]int{1, 5}, "B")
+	assertSlice(m.Values(), []string{"a", "B", "c"}, "m.Values()", t)
+
+	values := make([]string, 0)
+	for _, v := range m.Range([2]int{1, 3}, [2]int{2, 1}) {
+		values = append(values, v)
+	}
+	assertSlice(values, []string{"B"}, "m.Range() should use the less function", t)
+}
+
+// Test telling a missing key from one holding the zero value
+func TestAvlMapGetVariants(t *testing.T) {
+	m := NewAvlMap[string, int]()
+	m.Put("zero", 0)
+	m.Put("one", 1)
+
+	value, ok := m.Get("zero")
+	assert(value, 0, "m.Get(\"zero\")", t)
+	assert(ok, true, "m.Get(\"zero\") ok", t)
+	_, ok = m.Get("two")
+	assert(ok, false, "m.Get(\"two\") ok", t)
+
+	assert(m.MustGet("one"), 1, "m.MustGet(\"one\")", t)
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			assert(errors.Is(err, ErrNotFound), true, "m.MustGet(\"two\") panics with ErrNotFound", t)
+		}()
+		m.MustGet("two")
+	}()
+
+	assert(m.GetOrDefault("zero", -1), 0, "m.GetOrDefault(\"zero\")", t)
+	assert(m.GetOrDefault("two", -1), -1, "m.GetOrDefault(\"two\")", t)
+}
+
+// Test looking up keys by rank and by index
+func TestAvlMapRankAccess(t *testing.T) {
+	m := NewAvlMap[int, string]()
+	for k := 10; k <= 50; k += 10 {
+		m.Put(k, fmt.Sprint("v", k))
+	}
+	rank, ok := m.RankOfKey(40)
+	assert(rank, 3, "m.RankOfKey(40)", t)
+	assert(ok, true, "m.RankOfKey(40) ok", t)
+	rank, ok = m.RankOfKey(15)
+	assert(rank, 1, "m.RankOfKey(15)", t)
+	assert(ok, false, "m.RankOfKey(15) ok", t)
+
+	key, err := m.KeyAt(1)
+	assert(key, 20, "m.KeyAt(1)", t)
+	assert(err, nil, "m.KeyAt(1) error", t)
+	key, value, err := m.EntryAt(4)
+	assert(fmt.Sprint(key, "=", value), "50=v50", "m.EntryAt(4)", t)
+	assert(err, nil, "m.EntryAt(4) error", t)
+	_, err = m.KeyAt(5)
+	assert(errors.Is(err, ErrValueOutOfRange), true, "m.KeyAt(5) is ErrValueOutOfRange", t)
+	_, _, err = m.EntryAt(-1)
+	assert(errors.Is(err, ErrValueOutOfRange), true, "m.EntryAt(-1) is ErrValueOutOfRange", t)
+}
+
+// Test iterating from a key in both directions, with and without the key
+func TestAvlMapIterateFrom(t *testing.T) {
+	m := NewAvlMap[int, string]()
+	for k := 10; k <= 50; k += 10 {
+		m.Put(k, fmt.Sprint("v", k))
+	}
+	collect := func(seq func(func(int, string) bool)) []int {
+		keys := make([]int, 0)
+		for k := range seq {
+			keys = append(keys, k)
+		}
+		return keys
+	}
+	assertSlice(collect(m.IterateFrom(30, true, false)), []int{30, 40, 50}, "m.IterateFrom(30, inclusive, ascending)", t)
+	assertSlice(collect(m.IterateFrom(30, false, false)), []int{40, 50}, "m.IterateFrom(30, exclusive, ascending)", t)
+	assertSlice(collect(m.IterateFrom(30, true, true)), []int{30, 20, 10}, "m.IterateFrom(30, inclusive, descending)", t)
+	assertSlice(collect(m.IterateFrom(35, false, true)), []int{30, 20, 10}, "m.IterateFrom(35, exclusive, descending)", t)
+	for k, v := range m.IterateFrom(10, true, false) {
+		assert(v, fmt.Sprint("v", k), "m.IterateFrom() values", t)
+		break
+	}
+}
@@ -0,0 +1,155 @@
+// Package avltest provides a differential testing harness that applies
+// sequences of operations to an avl.AvlTree and to a simple sorted-slice
+// reference model, reporting the first result on which they disagree.
+//
+// The harness is driven by arbitrary bytes, which makes it suitable as the
+// body of a fuzz target. Packages that wrap the tree can extend DefaultOps
+// with operations of their own.
+package avltest
+
+import (
+	"fmt"
+	"slices"
+
+	avl "github.com/al-ce/go-avltree"
+)
+
+// Model is the reference implementation the tree is checked against: its
+// values in a sorted slice.
+type Model struct {
+	Values []int
+}
+
+// Insert a value into the model
+func (model *Model) Add(value int) {
+	i, _ := slices.BinarySearch(model.Values, value+1)
+	model.Values = slices.Insert(model.Values, i, value)
+}
+
+// Remove a value from the model.
+// Returns true on successful removal, false if value was not found.
+func (model *Model) Remove(value int) bool {
+	i, found := slices.BinarySearch(model.Values, value)
+	if found {
+		model.Values = slices.Delete(model.Values, i, i+1)
+	}
+	return found
+}
+
+// Returns the number of values in the model strictly less than value
+func (model *Model) CountLess(value int) int {
+	i, _ := slices.BinarySearch(model.Values, value)
+	return i
+}
+
+// Op is an operation applied with the same argument to a tree and to the
+// model. Apply returns an error describing any disagreement.
+type Op struct {
+	Name  string
+	Apply func(tree *avl.AvlTree[int], model *Model, arg int) error
+}
+
+// Returns the operations covering the tree's public API
+func DefaultOps() []Op {
+	return []Op{
+		{"Add", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+			tree.Add(arg)
+			model.Add(arg)
+			return nil
+		}},
+		{"Remove", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+			return expect(tree.Remove(arg), model.Remove(arg))
+		}},
+		{"Contains", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+			return expect(tree.Contains(arg), slices.Contains(model.Values, arg))
+		}},
+		{"PopMin", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+			value, err := tree.PopMin()
+			if len(model.Values) == 0 {
+				return expect(err != nil, true)
+			}
+			expected := model.Values[0]
+			model.Values = model.Values[1:]
+			return expect(value, expected)
+		}},
+		{"PopMax", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+			value, err := tree.PopMax()
+			if len(model.Values) == 0 {
+				return expect(err != nil, true)
+			}
+			expected := model.Values[len(model.Values)-1]
+			model.Values = model.Values[:len(model.Values)-1]
+			return expect(value, expected)
+		}},
+		{"CountLess", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+			return expect(tree.CountLess(arg), model.CountLess(arg))
+		}},
+		{"PartitionPoint", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+			_, rank, _ := tree.PartitionPoint(func(v int) bool { return v < arg })
+			return expect(rank, model.CountLess(arg))
+		}},
+		{"Page", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+			offset := max(arg, 0) % (len(model.Values) + 1)
+			end := min(offset+3, len(model.Values))
+			return expect(fmt.Sprint(tree.Page(offset, 3)), fmt.Sprint(model.Values[offset:end]))
+		}},
+		{"Clear", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+			// Only clear occasionally so trees get a chance to grow
+			if arg%16 == 0 {
+				tree.Clear()
+				model.Values = model.Values[:0]
+			}
+			return nil
+		}},
+	}
+}
+
+// Decode data into a sequence of operations, two bytes each: the operation
+// index and a small signed argument, so that values collide often. Each
+// operation is applied to a new tree and model, and their contents, size and
+// extremes are compared after every step.
+// Returns the first disagreement found, naming the step that caused it.
+func Run(data []byte, ops []Op) error {
+	tree := avl.NewAvlTree[int]()
+	model := &Model{}
+	for step := 0; step+1 < len(data); step += 2 {
+		op := ops[int(data[step])%len(ops)]
+		arg := int(int8(data[step+1])) / 4
+		if err := op.Apply(tree, model, arg); err != nil {
+			return fmt.Errorf("step %d, %s(%d): %w", step/2, op.Name, arg, err)
+		}
+		if err := compare(tree, model); err != nil {
+			return fmt.Errorf("step %d, after %s(%d): %w", step/2, op.Name, arg, err)
+		}
+	}
+	return nil
+}
+
+// Compare the observable state of the tree and the model
+func compare(tree *avl.AvlTree[int], model *Model) error {
+	if !slices.Equal(tree.InOrderTraverse(), model.Values) {
+		return fmt.Errorf("contents %v, expected %v", tree.InOrderTraverse(), model.Values)
+	}
+	if tree.Size() != len(model.Values) {
+		return fmt.Errorf("size %d, expected %d", tree.Size(), len(model.Values))
+	}
+	minVal, minErr := tree.GetMin()
+	maxVal, maxErr := tree.GetMax()
+	if len(model.Values) == 0 {
+		return expect(minErr != nil && maxErr != nil, true)
+	}
+	if err := expect(minVal, model.Values[0]); err != nil {
+		return fmt.Errorf("min: %w", err)
+	}
+	if err := expect(maxVal, model.Values[len(model.Values)-1]); err != nil {
+		return fmt.Errorf("max: %w", err)
+	}
+	return nil
+}
+
+func expect[T comparable](actual, expected T) error {
+	if actual != expected {
+		return fmt.Errorf("got %v, expected %v", actual, expected)
+	}
+	return nil
+}
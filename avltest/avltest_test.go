@@ -0,0 +1,33 @@
+package avltest
+
+import (
+	"testing"
+
+	avl "github.com/al-ce/go-avltree"
+)
+
+// Fuzz the tree against the reference model with the default operations
+func FuzzTree(f *testing.F) {
+	f.Add([]byte{0, 4, 0, 8, 0, 12, 1, 8, 5, 10})
+	f.Add([]byte{0, 200, 0, 100, 0, 100, 3, 0, 4, 0, 6, 100, 7, 1, 8, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := Run(data, DefaultOps()); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// Test that a disagreement introduced by an extra operation is reported
+func TestRunReportsMismatch(t *testing.T) {
+	broken := Op{"AddTwice", func(tree *avl.AvlTree[int], model *Model, arg int) error {
+		tree.Add(arg)
+		tree.Add(arg)
+		model.Add(arg)
+		return nil
+	}}
+	ops := append(DefaultOps(), broken)
+	err := Run([]byte{byte(len(ops) - 1), 4}, ops)
+	if err == nil {
+		t.Errorf("Run() with a broken op returned nil")
+	}
+}
@@ -0,0 +1,38 @@
+package avl
+
+import "math/big"
+
+// Comparer is implemented by types with a Cmp method returning a negative
+// number, zero or a positive number as the receiver is less than, equal to or
+// greater than the argument, such as *big.Int, *big.Float and *big.Rat, and
+// the decimal types of most arbitrary-precision packages.
+type Comparer[T any] interface {
+	Cmp(T) int
+}
+
+// Returns a bool indicating whether a.Cmp(b) is negative
+func CmpLess[T Comparer[T]](a, b T) bool {
+	return a.Cmp(b) < 0
+}
+
+// Returns a tree of values ordered by their Cmp method, for exact-precision
+// keys. Since Cmp methods on pointer receivers can't take nil, the tree
+// rejects nil values as with WithNilPolicy(NilRejected), unless the options
+// set another policy. The tree holds the pointers it is given, so a value
+// changed after it is added breaks the order; use WithCloneFunc to keep
+// copies, e.g. with func(x *big.Int) *big.Int { return new(big.Int).Set(x) }.
+func NewCmpTree[T Comparer[T]](opts ...TreeOption) *AvlTree[T] {
+	return NewAvlTreeFunc(CmpLess[T], append([]TreeOption{WithNilPolicy(NilRejected)}, opts...)...)
+}
+
+// Returns a tree of *big.Int values in numerical order. See NewCmpTree.
+func NewBigIntTree(opts ...TreeOption) *AvlTree[*big.Int] {
+	return NewCmpTree[*big.Int](opts...)
+}
+
+// Returns a tree of *big.Float values in numerical order, in which values
+// of different precisions are equal if they denote the same number, as are
+// -0 and +0. See NewCmpTree.
+func NewBigFloatTree(opts ...TreeOption) *AvlTree[*big.Float] {
+	return NewCmpTree[*big.Float](opts...)
+}
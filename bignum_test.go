@@ -0,0 +1,51 @@
+package avl
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestBigIntTree(t *testing.T) {
+	tree := NewBigIntTree()
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	for _, x := range []*big.Int{big.NewInt(5), huge, big.NewInt(-7), new(big.Int).Neg(huge), big.NewInt(0)} {
+		tree.Add(x)
+	}
+	got := make([]string, 0)
+	for _, x := range tree.InOrderTraverse() {
+		got = append(got, x.String())
+	}
+	assertSlice(got, []string{"-123456789012345678901234567890", "-7", "0", "5", "123456789012345678901234567890"}, "big.Int order", t)
+	assert(tree.Contains(big.NewInt(5)), true, "Contains should compare by value", t)
+	assert(errors.Is(tree.TryAdd(nil), ErrInvalidArgument), true, "nil should be rejected", t)
+	assert(tree.Contains(nil), false, "Contains(nil)", t)
+	assertBalanced(tree, "big.Int tree", t)
+
+	last := NewBigIntTree(WithNilPolicy(NilLast))
+	last.Add(nil)
+	last.Add(big.NewInt(1))
+	assert(last.InOrderTraverse()[1] == nil, true, "options should override the nil policy", t)
+
+	cloned := NewBigIntTree(WithCloneFunc(func(x *big.Int) *big.Int { return new(big.Int).Set(x) }))
+	x := big.NewInt(10)
+	cloned.Add(x)
+	x.SetInt64(-10)
+	assert(cloned.Contains(big.NewInt(10)), true, "a cloned value should not change with the original", t)
+}
+
+func TestBigFloatTree(t *testing.T) {
+	tree := NewBigFloatTree()
+	third := new(big.Float).SetPrec(200).Quo(big.NewFloat(1), big.NewFloat(3))
+	for _, x := range []*big.Float{big.NewFloat(0.5), third, new(big.Float).SetInf(true), big.NewFloat(-1)} {
+		tree.Add(x)
+	}
+	assert(tree.InOrderTraverse()[0].String(), "-Inf", "big.Float order", t)
+	assert(tree.InOrderTraverse()[2], third, "big.Float order", t)
+	assert(tree.Contains(new(big.Float).SetPrec(10).SetFloat64(0.5)), true, "precision should not affect equality", t)
+
+	rats := NewCmpTree[*big.Rat]()
+	rats.Add(big.NewRat(2, 3))
+	rats.Add(big.NewRat(1, 3))
+	assert(rats.Contains(big.NewRat(2, 6)), true, "big.Rat values should compare by value", t)
+}
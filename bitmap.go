@@ -0,0 +1,69 @@
+package avl
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/constraints"
+)
+
+// BitmapValue is the set of value types used by compressed bitmap libraries
+// such as roaring, whose 32 and 64-bit variants hold uint32 and uint64 values.
+type BitmapValue interface {
+	uint32 | uint64
+}
+
+// Write the tree's values to emit in ascending batches of up to len(buf)
+// values, converted for a compressed bitmap, e.g. with emit calling the
+// bitmap's AddMany. Duplicate values are written once, since bitmaps are sets.
+// The batch passed to emit is only valid until it returns.
+// Returns an error if buf is empty, if a value doesn't fit in U, or the first
+// error returned by emit, in which case later values aren't written.
+func ExportBitmap[T constraints.Integer, U BitmapValue](tree *AvlTree[T], buf []U, emit func([]U) error) error {
+	if len(buf) == 0 {
+		return tree.misuse(fmt.Errorf("buffer is empty: %w", ErrInvalidArgument))
+	}
+	n, written := 0, false
+	var last U
+	for node := tree.minNode(); node != nil; node = nextNode(node) {
+		v := node.value
+		if v < 0 || uint64(v) > uint64(^U(0)) {
+			return fmt.Errorf("%w: %v", ErrValueOutOfRange, v)
+		}
+		if written && U(v) == last {
+			continue
+		}
+		if n == len(buf) {
+			if err := emit(buf); err != nil {
+				return err
+			}
+			n = 0
+		}
+		buf[n], last, written = U(v), U(v), true
+		n += 1
+	}
+	if n > 0 {
+		return emit(buf[:n])
+	}
+	return nil
+}
+
+// Add values read from a compressed bitmap to the tree, in batches filled by
+// next until it returns 0, e.g. with next calling NextMany on the bitmap's
+// iterator. Values already in the tree are added again like any other Add.
+// Returns an error if buf is empty or a value doesn't fit in T, in which case
+// the values before it in the batch have been added.
+func ImportBitmap[T constraints.Integer, U BitmapValue](tree *AvlTree[T], buf []U, next func([]U) int) error {
+	if len(buf) == 0 {
+		return tree.misuse(fmt.Errorf("buffer is empty: %w", ErrInvalidArgument))
+	}
+	for n := next(buf); n > 0; n = next(buf) {
+		for _, u := range buf[:n] {
+			v := T(u)
+			if v < 0 || U(v) != u {
+				return fmt.Errorf("%w: %v", ErrValueOutOfRange, u)
+			}
+			tree.Add(v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package avl
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// Test exporting to and importing from a bitmap stand-in, in batches smaller
+// than the tree
+func TestBitmapRoundTrip(t *testing.T) {
+	tree := populateTree(t, []int{7, 3, 3, 9, 1, 3, 12, 5})
+	bitmap := make([]uint32, 0)
+	batches := 0
+	err := ExportBitmap(tree, make([]uint32, 2), func(batch []uint32) error {
+		bitmap = append(bitmap, batch...)
+		batches += 1
+		return nil
+	})
+	assert(err, nil, "ExportBitmap()", t)
+	assertSlice(bitmap, []uint32{1, 3, 5, 7, 9, 12}, "exported values", t)
+	assert(batches, 3, "exported batches", t)
+
+	imported := NewAvlTree[int]()
+	remaining := slices.Clone(bitmap)
+	err = ImportBitmap(imported, make([]uint32, 4), func(buf []uint32) int {
+		n := copy(buf, remaining)
+		remaining = remaining[n:]
+		return n
+	})
+	assert(err, nil, "ImportBitmap()", t)
+	assertSlice(imported.InOrderTraverse(), []int{1, 3, 5, 7, 9, 12}, "imported values", t)
+}
+
+// Test that values that don't fit are reported
+func TestBitmapOutOfRange(t *testing.T) {
+	negative := populateTree(t, []int{-1, 2})
+	err := ExportBitmap(negative, make([]uint64, 8), func([]uint64) error { return nil })
+	assert(err != nil, true, "ExportBitmap() of a negative value", t)
+
+	wide := NewAvlTree[uint64]()
+	wide.Add(math.MaxUint32 + 1)
+	err = ExportBitmap(wide, make([]uint32, 8), func([]uint32) error { return nil })
+	assert(err != nil, true, "ExportBitmap() of a value above uint32", t)
+
+	small := NewAvlTree[int8]()
+	sent := false
+	err = ImportBitmap(small, make([]uint32, 8), func(buf []uint32) int {
+		if sent {
+			return 0
+		}
+		sent = true
+		buf[0], buf[1] = 5, 300
+		return 2
+	})
+	assert(err != nil, true, "ImportBitmap() of a value above int8", t)
+	assertSlice(small.InOrderTraverse(), []int8{5}, "values imported before the error", t)
+
+	err = ExportBitmap(small, []uint32{}, func([]uint32) error { return nil })
+	assert(err != nil, true, "ExportBitmap() with an empty buffer", t)
+}
@@ -0,0 +1,42 @@
+package avl
+
+import (
+	"context"
+	"iter"
+	"runtime"
+)
+
+// Number of values BulkAdd adds between checks of its context
+const bulkBatchSize = 4096
+
+// Add every value from src to the tree like Add, for loads large enough that
+// a caller serving requests or drawing a UI can't block on them. Every 4096
+// values the load reports the number of values added so far to progress,
+// which may be nil, checks ctx and yields the processor to other goroutines.
+// progress is also called once the load completes.
+// Returns the context's error if it is done before src is exhausted, leaving
+// the values added until then in the tree.
+func (tree *AvlTree[T]) BulkAdd(ctx context.Context, src iter.Seq[T], progress func(done int)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := 0
+	for v := range src {
+		tree.Add(v)
+		done += 1
+		if done%bulkBatchSize != 0 {
+			continue
+		}
+		if progress != nil {
+			progress(done)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		runtime.Gosched()
+	}
+	if progress != nil && done%bulkBatchSize != 0 {
+		progress(done)
+	}
+	return nil
+}
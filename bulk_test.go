@@ -0,0 +1,36 @@
+package avl
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// Test that BulkAdd adds every value and reports progress once per batch and
+// at the end
+func TestBulkAdd(t *testing.T) {
+	tree := NewAvlTree[int]()
+	values := rangeWithSteps(1, 2*bulkBatchSize+10, 1)
+	reports := make([]int, 0)
+	err := tree.BulkAdd(context.Background(), slices.Values(values), func(done int) {
+		reports = append(reports, done)
+	})
+	assert(err, nil, "tree.BulkAdd() error", t)
+	assertSlice(reports, []int{bulkBatchSize, 2 * bulkBatchSize, len(values)}, "progress reports", t)
+	assertSlice(tree.InOrderTraverse(), values, "tree after BulkAdd", t)
+	assertBalanced(tree, "tree after BulkAdd", t)
+}
+
+// Test that BulkAdd stops at the first batch boundary after cancellation
+func TestBulkAddCanceled(t *testing.T) {
+	tree := NewAvlTree[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	err := tree.BulkAdd(ctx, slices.Values(rangeWithSteps(1, 3*bulkBatchSize, 1)), func(done int) {
+		cancel()
+	})
+	assert(err, context.Canceled, "tree.BulkAdd() error after cancel", t)
+	assert(tree.Len(), bulkBatchSize, "tree.Len() after a canceled BulkAdd", t)
+
+	err = NewAvlTree[int]().BulkAdd(ctx, slices.Values([]int{1}), nil)
+	assert(err, context.Canceled, "tree.BulkAdd() with a done context", t)
+}
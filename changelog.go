@@ -0,0 +1,321 @@
+package avl
+
+import (
+	"fmt"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+// ChangeOp identifies the kind of mutation described by a ChangeRecord.
+type ChangeOp int
+
+const (
+	ChangeAdd ChangeOp = iota
+	ChangeRemove
+	ChangeClear
+	// The value replaced the one at Rank in place, as with UpdateValue
+	ChangeUpdate
+)
+
+// ChangeRecord describes a single mutation of the tree. Version is the value
+// of the tree's version counter after the mutation was applied.
+type ChangeRecord[T any] struct {
+	Version uint64
+	Op      ChangeOp
+	Value   T
+	// Index in sorted order of the value a ChangeRemove removed or a
+	// ChangeUpdate replaced, so that replaying it changes the same one of
+	// several equal values
+	Rank int
+}
+
+// PruneStats reports the history discarded by PruneVersionsBefore. Old
+// versions are kept as change records replayed over one snapshot of values,
+// not as trees sharing nodes, so pruning discards records rather than nodes.
+type PruneStats struct {
+	// Number of change records folded into the snapshot and discarded
+	RecordsDiscarded int
+	// Values held by the snapshot the retained records replay over
+	SnapshotValues int
+	// Oldest version still readable with AsOf
+	OldestVersion uint64
+}
+
+// changeLog holds the recorded mutations of a tree along with a snapshot of
+// the tree's values at baseVersion, the version preceding the oldest record.
+type changeLog[T any] struct {
+	base        []T
+	baseVersion uint64
+	records     []ChangeRecord[T]
+	retention   int
+	// The tree's ordering, for rebuilding it from the snapshot
+	less func(a, b T) bool
+}
+
+// Start recording mutations so they can be read back with ChangeLog.
+// Returns the current version of the tree; a follower that copies the tree's
+// values at this version can stay synchronized by applying every record
+// returned by ChangeLog(version).
+func (tree *AvlTree[T]) EnableChangeLog() uint64 {
+	if tree.log == nil {
+		tree.log = &changeLog[T]{
+			base:        tree.InOrderTraverse(),
+			baseVersion: tree.version,
+			less:        tree.less,
+		}
+	}
+	return tree.version
+}
+
+// Returns the recorded mutations with a version greater than sinceVersion, in
+// the order they were applied. The returned slice is a copy and is safe to
+// retain, and its values are copied with the tree's clone func if it has one.
+// Returns an error wrapping ErrVersionNotRetained if the change log is not
+// enabled, or if records after sinceVersion were already discarded by
+// TruncateChangeLog, PruneVersionsBefore or the retention limit, since the
+// records left would not bring a follower at sinceVersion up to date. Such a
+// follower must copy the tree's values again.
+func (tree *AvlTree[T]) ChangeLog(sinceVersion uint64) ([]ChangeRecord[T], error) {
+	if tree.log == nil {
+		return nil, fmt.Errorf("change log is not enabled: %w", ErrVersionNotRetained)
+	}
+	if sinceVersion < tree.log.baseVersion {
+		return nil, fmt.Errorf("%w: records after version %d were discarded up to version %d",
+			ErrVersionNotRetained, sinceVersion, tree.log.baseVersion)
+	}
+	i := tree.log.index(sinceVersion)
+	records := slices.Clone(tree.log.records[i:])
+	if tree.clone != nil {
+		for i := range records {
+			records[i].Value = tree.clone(records[i].Value)
+		}
+	}
+	return records, nil
+}
+
+// Returns the oldest version the change log still has every later record
+// for: a follower at this version or later can catch up with ChangeLog, and
+// it is also the oldest version AsOf can read. Returns the current version if
+// the change log is not enabled.
+func (tree *AvlTree[T]) OldestRetainedVersion() uint64 {
+	if tree.log == nil {
+		return tree.version
+	}
+	return tree.log.baseVersion
+}
+
+// Discard recorded mutations with a version less than or equal to
+// upToVersion, bounding the memory used by the change log once all followers
+// have caught up. Versions older than upToVersion can no longer be read with
+// AsOf.
+func (tree *AvlTree[T]) TruncateChangeLog(upToVersion uint64) {
+	if tree.log == nil {
+		return
+	}
+	tree.log.fold(tree.log.index(upToVersion))
+}
+
+// Discard the history needed to read versions older than the given version,
+// so long-running processes that take snapshots don't retain every mutation.
+// The given version remains readable with AsOf.
+func (tree *AvlTree[T]) PruneVersionsBefore(version uint64) PruneStats {
+	if tree.log == nil {
+		return PruneStats{OldestVersion: tree.version}
+	}
+	n := tree.log.index(version)
+	tree.log.fold(n)
+	return PruneStats{
+		RecordsDiscarded: n,
+		SnapshotValues:   len(tree.log.base),
+		OldestVersion:    tree.log.baseVersion,
+	}
+}
+
+// Keep at most maxRecords mutations in the change log, discarding the oldest
+// ones as new mutations are recorded. A maxRecords of 0 retains every record.
+func (tree *AvlTree[T]) SetChangeLogRetention(maxRecords int) {
+	tree.EnableChangeLog()
+	tree.log.retention = maxRecords
+	tree.log.enforceRetention()
+}
+
+// Add the value like Add, and return the ID of the operation: the tree's
+// version after the addition, which is also the Version of its change log
+// record. IDs increase by one with every mutation of the tree, so a
+// replication layer can order operations and drop ones it has already seen.
+func (tree *AvlTree[T]) AddVersioned(value T) uint64 {
+	tree.Add(value)
+	return tree.version
+}
+
+// Remove the value like Remove, and return the ID of the operation as
+// AddVersioned does. Returns false, and the current version, if the value
+// was not found, since nothing was changed.
+func (tree *AvlTree[T]) RemoveVersioned(value T) (uint64, bool) {
+	removed := tree.Remove(value)
+	return tree.version, removed
+}
+
+// Apply records produced by another tree's ChangeLog, in order. A
+// ChangeRemove removes the value at its rank if that value is equal to it,
+// and otherwise the first equal value, if any. Like a ChangeRemove of a
+// missing value, a ChangeUpdate is skipped if the tree holds no value at its
+// rank, or the value doesn't fit there.
+func (tree *AvlTree[T]) ApplyChanges(records []ChangeRecord[T]) {
+	for _, record := range records {
+		switch record.Op {
+		case ChangeAdd:
+			tree.Add(record.Value)
+		case ChangeRemove:
+			tree.removeAt(record.Rank, record.Value)
+		case ChangeClear:
+			tree.Clear()
+		case ChangeUpdate:
+			if node := tree.nodeAt(record.Rank); node != nil && tree.checkUpdate(node, record.Value) == nil {
+				tree.updateNode(node, record.Rank, record.Value)
+			}
+		}
+	}
+}
+
+// Op is an operation in an event log to be replayed with ReplayOps. Value is
+// ignored for ChangeClear, and Rank by ChangeAdd and ChangeClear, as in a
+// ChangeRecord.
+type Op[T any] struct {
+	Kind  ChangeOp
+	Value T
+	Rank  int
+}
+
+// Returns a new tree built by applying the operations in order, for
+// event-sourced systems that rebuild an index from its history. The tree's
+// shape depends only on the sequence of operations, so replaying the same
+// sequence always gives a tree with the same structure, as printed by Print,
+// and the same version.
+// Unlike ApplyChanges, replay is strict: returns an error naming the
+// operation if it removes a value that is not in the tree or updates a rank
+// past the end, wrapping ErrNotFound, updates a value out of order, wrapping
+// ErrValueOutOfRange, or has an unknown kind, wrapping ErrInvalidArgument,
+// since each means the log doesn't describe the state it was recorded from.
+func ReplayOps[T constraints.Ordered](ops []Op[T]) (*AvlTree[T], error) {
+	tree := NewAvlTree[T]()
+	for i, op := range ops {
+		switch op.Kind {
+		case ChangeAdd:
+			tree.Add(op.Value)
+		case ChangeRemove:
+			if !tree.removeAt(op.Rank, op.Value) {
+				return nil, fmt.Errorf("op %d: removing %v: %w", i, op.Value, ErrNotFound)
+			}
+		case ChangeClear:
+			tree.Clear()
+		case ChangeUpdate:
+			node := tree.nodeAt(op.Rank)
+			if node == nil {
+				return nil, fmt.Errorf("op %d: updating rank %d of %d values: %w", i, op.Rank, tree.size, ErrNotFound)
+			}
+			if err := tree.checkUpdate(node, op.Value); err != nil {
+				return nil, fmt.Errorf("op %d: %w", i, err)
+			}
+			tree.updateNode(node, op.Rank, op.Value)
+		default:
+			return nil, fmt.Errorf("op %d: unknown kind %d: %w", i, op.Kind, ErrInvalidArgument)
+		}
+	}
+	return tree, nil
+}
+
+// Returns a read-only view of the values this tree held at the given version.
+// Versions share no nodes: each call rebuilds the version from the oldest
+// retained snapshot and replays the records after it, in O(n + k log n) for
+// n values in the snapshot and k records, so callers reading one version
+// repeatedly should keep the view. The view is of a tree of its own, so
+// range queries can run against it while writes to the receiver continue,
+// and its Version is the version requested.
+// Returns an error if the change log is not enabled or the version is outside
+// of the retained history.
+func (tree *AvlTree[T]) AsOf(version uint64) (ReadOnlyTree[T], error) {
+	if tree.log == nil {
+		return ReadOnlyTree[T]{}, fmt.Errorf("change log is not enabled: %w", ErrVersionNotRetained)
+	}
+	if version < tree.log.baseVersion || version > tree.version {
+		return ReadOnlyTree[T]{}, fmt.Errorf("%w: %d", ErrVersionNotRetained, version)
+	}
+	snapshot := tree.log.replay(tree.log.index(version))
+	snapshot.version = version
+	snapshot.clone = tree.clone
+	return snapshot.ReadOnly(), nil
+}
+
+// Bump the version counter and, if enabled, record the mutation and update
+// the negative lookup filter
+func (tree *AvlTree[T]) recordChange(op ChangeOp, value T) {
+	tree.recordChangeAt(op, 0, value)
+}
+
+// Record a mutation as recordChange does, along with the rank of the value
+// it changed
+func (tree *AvlTree[T]) recordChangeAt(op ChangeOp, rank int, value T) {
+	tree.updateFilter(op, value)
+	tree.version += 1
+	if tree.log != nil {
+		tree.log.records = append(tree.log.records, ChangeRecord[T]{
+			Version: tree.version,
+			Op:      op,
+			Value:   value,
+			Rank:    rank,
+		})
+		tree.log.enforceRetention()
+	}
+}
+
+// Remove the value at the given rank if it is equal to value, or else the
+// first value equal to it, as a logged removal is replayed.
+// Returns true on successful removal, false if value was not found.
+func (tree *AvlTree[T]) removeAt(rank int, value T) bool {
+	if node := tree.nodeAt(rank); node != nil && tree.equal(node.value, value) {
+		tree.removeNode(node)
+		return true
+	}
+	return tree.Remove(value)
+}
+
+// Returns the index of the first record with a version greater than the given
+// version
+func (log *changeLog[T]) index(version uint64) int {
+	i, _ := slices.BinarySearchFunc(log.records, version,
+		func(record ChangeRecord[T], v uint64) int {
+			if record.Version <= v {
+				return -1
+			}
+			return 1
+		})
+	return i
+}
+
+// Returns a tree built from the base snapshot with the first n records applied
+func (log *changeLog[T]) replay(n int) *AvlTree[T] {
+	snapshot := newTree(log.less)
+	snapshot.replaceValues(log.base)
+	snapshot.ApplyChanges(log.records[:n])
+	return snapshot
+}
+
+// Fold the first n records into the base snapshot
+func (log *changeLog[T]) fold(n int) {
+	if n == 0 {
+		return
+	}
+	log.base = log.replay(n).InOrderTraverse()
+	log.baseVersion = log.records[n-1].Version
+	log.records = slices.Delete(log.records, 0, n)
+}
+
+// Folding rebuilds the base snapshot, so let the log grow to twice the
+// retention before trimming it back to amortize the cost
+func (log *changeLog[T]) enforceRetention() {
+	if log.retention > 0 && len(log.records) > 2*log.retention {
+		log.fold(len(log.records) - log.retention)
+	}
+}
@@ -0,0 +1,267 @@
+package avl
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// Returns the change log since the version, failing the test on error
+func changesSince[T any](t *testing.T, tree *AvlTree[T], version uint64) []ChangeRecord[T] {
+	t.Helper()
+	records, err := tree.ChangeLog(version)
+	if err != nil {
+		t.Fatalf("ChangeLog(%d): %v", version, err)
+	}
+	return records
+}
+
+// Test that a follower applying the change log stays in sync with the leader
+func TestChangeLogReplication(t *testing.T) {
+	leader := populateTree(t, []int{5, 3, 8})
+	version := leader.EnableChangeLog()
+
+	follower := populateTree(t, leader.InOrderTraverse())
+
+	leader.Add(1)
+	leader.Remove(8)
+	leader.Add(9)
+
+	records := changesSince(t, leader, version)
+	assert(len(records), 3, "len(ChangeLog(version))", t)
+	for i := 1; i < len(records); i++ {
+		assert(records[i].Version > records[i-1].Version, true, "ChangeLog version order", t)
+	}
+
+	follower.ApplyChanges(records)
+	assertSlice(follower.InOrderTraverse(), leader.InOrderTraverse(), "follower after ApplyChanges", t)
+
+	// Incremental sync from the last applied version
+	last := records[len(records)-1].Version
+	leader.Clear()
+	leader.Add(42)
+	follower.ApplyChanges(changesSince(t, leader, last))
+	assertSlice(follower.InOrderTraverse(), []int{42}, "follower after Clear", t)
+}
+
+// Test that failed removals are not recorded and truncation drops old records
+func TestChangeLogTruncate(t *testing.T) {
+	tree := NewAvlTree[int]()
+	tree.EnableChangeLog()
+	for _, v := range []int{1, 2, 3} {
+		tree.Add(v)
+	}
+	tree.Remove(10)
+	records := changesSince(t, tree, 0)
+	assert(len(records), 3, "len(ChangeLog(0))", t)
+
+	tree.TruncateChangeLog(records[1].Version)
+	assert(tree.OldestRetainedVersion(), records[1].Version, "tree.OldestRetainedVersion() after truncate", t)
+	remaining := changesSince(t, tree, records[1].Version)
+	assert(len(remaining), 1, "len(ChangeLog()) after truncate", t)
+	assert(remaining[0].Value, 3, "ChangeLog()[0].Value after truncate", t)
+
+	// A follower from before the truncation can't catch up from the log
+	_, err := tree.ChangeLog(0)
+	assert(errors.Is(err, ErrVersionNotRetained), true, "ChangeLog(0) after truncate is ErrVersionNotRetained", t)
+	_, err = NewAvlTree[int]().ChangeLog(0)
+	assert(errors.Is(err, ErrVersionNotRetained), true, "ChangeLog() without change log is ErrVersionNotRetained", t)
+
+	// Returned records are copies
+	remaining[0].Value = 100
+	assert(slices.Equal(changesSince(t, tree, records[1].Version), remaining), false, "ChangeLog copy", t)
+}
+
+// Test reading historical versions of the tree while writes continue
+func TestAsOf(t *testing.T) {
+	tree := populateTree(t, []int{2, 4})
+	_, err := tree.AsOf(0)
+	assert(err != nil, true, "tree.AsOf() without change log", t)
+
+	start := tree.EnableChangeLog()
+	tree.Add(6)
+	afterAdd := changesSince(t, tree, start)[0].Version
+	tree.Remove(2)
+	tree.Add(8)
+
+	snapshot, err := tree.AsOf(afterAdd)
+	assert(err, nil, "tree.AsOf(afterAdd)", t)
+	assertSlice(snapshot.InOrderTraverse(), []int{2, 4, 6}, "tree.AsOf(afterAdd)", t)
+	assert(snapshot.Version(), afterAdd, "tree.AsOf(afterAdd).Version()", t)
+
+	snapshot, _ = tree.AsOf(start)
+	assertSlice(snapshot.InOrderTraverse(), []int{2, 4}, "tree.AsOf(start)", t)
+
+	// Snapshots are independent of the live tree
+	tree.Add(100)
+	assert(snapshot.Contains(100), false, "snapshot.Contains(100) after tree.Add", t)
+
+	_, err = tree.AsOf(start + 100)
+	assert(err != nil, true, "tree.AsOf(future version)", t)
+}
+
+// Test that the retention policy bounds the history that can be read
+func TestChangeLogRetention(t *testing.T) {
+	tree := NewAvlTree[int]()
+	tree.SetChangeLogRetention(2)
+	for i := 1; i <= 10; i++ {
+		tree.Add(i)
+	}
+	oldest := tree.OldestRetainedVersion()
+	assert(len(changesSince(t, tree, oldest)) <= 4, true, "len(ChangeLog()) with retention", t)
+	_, err := tree.ChangeLog(0)
+	assert(errors.Is(err, ErrVersionNotRetained), true, "ChangeLog(0) after retention", t)
+
+	_, err = tree.AsOf(1)
+	assert(err != nil, true, "tree.AsOf(1) after retention", t)
+
+	snapshot, err := tree.AsOf(oldest)
+	assert(err, nil, "tree.AsOf(oldest retained)", t)
+	assertSlice(snapshot.InOrderTraverse(), rangeWithSteps(1, int(oldest), 1), "tree.AsOf(oldest retained)", t)
+}
+
+// Test pruning history keeps the requested version readable
+func TestPruneVersionsBefore(t *testing.T) {
+	tree := NewAvlTree[int]()
+	tree.EnableChangeLog()
+	for i := 1; i <= 5; i++ {
+		tree.Add(i)
+	}
+
+	stats := tree.PruneVersionsBefore(3)
+	assert(stats.RecordsDiscarded, 3, "stats.RecordsDiscarded", t)
+	assert(stats.SnapshotValues, 3, "stats.SnapshotValues", t)
+	assert(stats.OldestVersion, uint64(3), "stats.OldestVersion", t)
+
+	_, err := tree.AsOf(2)
+	assert(err != nil, true, "tree.AsOf(2) after prune", t)
+	snapshot, err := tree.AsOf(3)
+	assert(err, nil, "tree.AsOf(3) after prune", t)
+	assertSlice(snapshot.InOrderTraverse(), []int{1, 2, 3}, "tree.AsOf(3) after prune", t)
+
+	stats = tree.PruneVersionsBefore(3)
+	assert(stats.RecordsDiscarded, 0, "stats.RecordsDiscarded (repeat)", t)
+}
+
+// Test that operation IDs follow the version and match the change log
+func TestVersionedOperations(t *testing.T) {
+	tree := NewAvlTree[int]()
+	tree.EnableChangeLog()
+	first := tree.AddVersioned(1)
+	second := tree.AddVersioned(2)
+	assert(second, first+1, "AddVersioned() IDs", t)
+
+	id, removed := tree.RemoveVersioned(1)
+	assert(removed, true, "RemoveVersioned() of a held value", t)
+	assert(id, second+1, "RemoveVersioned() ID", t)
+	unchanged, removed := tree.RemoveVersioned(1)
+	assert(removed, false, "RemoveVersioned() of a missing value", t)
+	assert(unchanged, id, "RemoveVersioned() ID of a missing value", t)
+
+	records := changesSince(t, tree, 0)
+	assert(len(records), 3, "len(ChangeLog(0))", t)
+	assert(records[2].Version, id, "ChangeLog(0)[2].Version", t)
+}
+
+// Test that replaying the same operations gives the same shape as applying
+// them directly, and that inconsistent logs are rejected
+func TestReplayOps(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	live := NewAvlTree[int]()
+	ops := make([]Op[int], 0)
+	for i := 0; i < 500; i++ {
+		v := r.Intn(100)
+		switch {
+		case i == 250:
+			live.Clear()
+			ops = append(ops, Op[int]{Kind: ChangeClear})
+		case live.Contains(v) && r.Intn(2) == 0:
+			live.Remove(v)
+			ops = append(ops, Op[int]{Kind: ChangeRemove, Value: v})
+		default:
+			live.Add(v)
+			ops = append(ops, Op[int]{Kind: ChangeAdd, Value: v})
+		}
+	}
+
+	replayed, err := ReplayOps(ops)
+	assert(err, nil, "ReplayOps() error", t)
+	assert(shapeString(replayed.root), shapeString(live.root), "shape of the replayed tree", t)
+	assert(replayed.Version(), live.Version(), "version of the replayed tree", t)
+	again, _ := ReplayOps(ops)
+	assert(shapeString(again.root), shapeString(replayed.root), "shape of a second replay", t)
+
+	_, err = ReplayOps([]Op[int]{{Kind: ChangeAdd, Value: 1}, {Kind: ChangeRemove, Value: 2}})
+	assert(errors.Is(err, ErrNotFound), true, "ReplayOps() removing a missing value", t)
+	_, err = ReplayOps([]Op[int]{{Kind: ChangeOp(9)}})
+	assert(errors.Is(err, ErrInvalidArgument), true, "ReplayOps() with an unknown kind", t)
+
+	updated, err := ReplayOps([]Op[int]{{Kind: ChangeAdd, Value: 1}, {Kind: ChangeAdd, Value: 5}, {Kind: ChangeUpdate, Value: 3, Rank: 1}})
+	assert(err, nil, "ReplayOps() with an update", t)
+	assertSlice(updated.InOrderTraverse(), []int{1, 3}, "ReplayOps() with an update", t)
+	_, err = ReplayOps([]Op[int]{{Kind: ChangeAdd, Value: 1}, {Kind: ChangeUpdate, Value: 3, Rank: 1}})
+	assert(errors.Is(err, ErrNotFound), true, "ReplayOps() updating past the end", t)
+	_, err = ReplayOps([]Op[int]{{Kind: ChangeAdd, Value: 1}, {Kind: ChangeAdd, Value: 5}, {Kind: ChangeUpdate, Value: 0, Rank: 1}})
+	assert(errors.Is(err, ErrValueOutOfRange), true, "ReplayOps() updating out of order", t)
+}
+
+// Test that replaying UpdateValue updates the same one of several equal
+// values, in a follower and in AsOf
+func TestChangeLogUpdateEqualKeys(t *testing.T) {
+	byTime := func(a, b record) bool { return a.time < b.time }
+	tree := NewAvlTreeFunc(byTime)
+	start := tree.EnableChangeLog()
+	tree.Add(record{1, "A"})
+	tree.Add(record{1, "B"})
+	node := tree.FindNode(record{time: 1})
+	node = nextNode(node)
+	assert(tree.UpdateValue(node, record{1, "B2"}), nil, "tree.UpdateValue(B)", t)
+	expected := []record{{1, "A"}, {1, "B2"}}
+	assertSlice(tree.InOrderTraverse(), expected, "tree after UpdateValue(B)", t)
+
+	records := changesSince(t, tree, start)
+	last := records[len(records)-1]
+	assert(last.Op, ChangeUpdate, "op recorded for UpdateValue", t)
+	assert(last.Rank, 1, "rank recorded for UpdateValue", t)
+
+	follower := NewAvlTreeFunc(byTime)
+	follower.ApplyChanges(records)
+	assertSlice(follower.InOrderTraverse(), expected, "follower after ApplyChanges", t)
+
+	snapshot, err := tree.AsOf(tree.Version())
+	assert(err, nil, "tree.AsOf() after UpdateValue", t)
+	assertSlice(snapshot.InOrderTraverse(), expected, "tree.AsOf() after UpdateValue", t)
+
+	// A follower that has diverged skips updates that no longer fit
+	short := NewAvlTreeFunc(byTime)
+	short.ApplyChanges(records[2:])
+	assert(short.IsEmpty(), true, "diverged follower after ApplyChanges", t)
+}
+
+// Test that replaying removals of the last of several equal values removes
+// the same value, for PopMax and DrainMax
+func TestChangeLogRemoveEqualKeys(t *testing.T) {
+	byTime := func(a, b record) bool { return a.time < b.time }
+	tree := NewAvlTreeFunc(byTime)
+	start := tree.EnableChangeLog()
+	for _, r := range []record{{1, "A"}, {2, "B"}, {2, "C"}, {2, "D"}, {2, "E"}} {
+		tree.Add(r)
+	}
+	popped, _ := tree.PopMax()
+	assert(popped, record{2, "E"}, "tree.PopMax()", t)
+	drained := tree.DrainMax(2)
+	assertSlice(drained, []record{{2, "D"}, {2, "C"}}, "tree.DrainMax(2)", t)
+	expected := []record{{1, "A"}, {2, "B"}}
+	assertSlice(tree.InOrderTraverse(), expected, "tree after PopMax and DrainMax", t)
+
+	follower := NewAvlTreeFunc(byTime)
+	follower.ApplyChanges(changesSince(t, tree, start))
+	assertSlice(follower.InOrderTraverse(), expected, "follower after ApplyChanges", t)
+	snapshot, _ := tree.AsOf(tree.Version())
+	assertSlice(snapshot.InOrderTraverse(), expected, "tree.AsOf() after PopMax and DrainMax", t)
+
+	// Removals found at another rank fall back to the first equal value
+	follower.ApplyChanges([]ChangeRecord[record]{{Op: ChangeRemove, Value: record{time: 2}, Rank: 5}})
+	assertSlice(follower.InOrderTraverse(), []record{{1, "A"}}, "follower after a removal past the end", t)
+}
@@ -0,0 +1,50 @@
+package avl
+
+import "time"
+
+// Clock is the source of time for the package's time-based structures, such
+// as DueQueue. Tests can pass a fake clock that they advance by hand, so that
+// expiry and scheduling can be checked deterministically and without
+// sleeping.
+type Clock interface {
+	// Returns the current time
+	Now() time.Time
+	// Returns a timer that sends the time on its channel once d has passed
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single-shot timer created by a Clock, like time.Timer.
+type Timer interface {
+	// Returns the channel the time is sent on when the timer fires
+	C() <-chan time.Time
+	// Stop the timer. Returns false if it has already fired or been stopped.
+	Stop() bool
+}
+
+// Returns the clock backed by the time package, the default for structures
+// that take a Clock
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
@@ -0,0 +1,32 @@
+package avl
+
+import "fmt"
+
+// Copy values with clone as they go into the tree, through Add, AppendMax,
+// UpdateValue, Merge or Graft, or into the result of Union, Intersect or
+// Difference, and as they come out of InOrderTraverse, All, Backward,
+// ChangeLog and the views returned by AsOf, for element types holding
+// slices, maps or pointers: the tree then keeps values no caller can reach,
+// so changing a value after adding it, or one read back, can't silently
+// break the tree's order. Other methods return the stored values as they
+// are. clone must return a value equal to its argument. Building a tree of
+// another element type than T with the option panics.
+func WithCloneFunc[T any](clone func(T) T) TreeOption {
+	return func(config *treeConfig) {
+		config.clone = clone
+	}
+}
+
+// %%% Tree private methods %%%
+
+// Apply the options that don't depend on the element type being ordered
+func (tree *AvlTree[T]) configure(config treeConfig) {
+	if config.clone != nil {
+		clone, ok := config.clone.(func(T) T)
+		if !ok {
+			panic(fmt.Errorf("clone func is %T, not %T: %w", config.clone, clone, ErrInvalidArgument))
+		}
+		tree.clone = clone
+	}
+	tree.applyNilPolicy(config.nilPolicy)
+}
@@ -0,0 +1,76 @@
+package avl
+
+import (
+	"slices"
+	"testing"
+)
+
+type tagged struct {
+	key  int
+	tags []string
+}
+
+func TestWithCloneFunc(t *testing.T) {
+	clone := func(v tagged) tagged {
+		v.tags = slices.Clone(v.tags)
+		return v
+	}
+	tree := NewAvlTreeFunc(func(a, b tagged) bool { return a.key < b.key }, WithCloneFunc(clone))
+
+	in := tagged{key: 1, tags: []string{"a"}}
+	tree.Add(in)
+	in.tags[0] = "changed"
+	for v := range tree.All() {
+		assert(v.tags[0], "a", "the tree should keep a copy of added values", t)
+		v.tags[0] = "changed"
+	}
+	assert(tree.InOrderTraverse()[0].tags[0], "a", "values read back should be copies", t)
+
+	tree.AppendMax(tagged{key: 2, tags: []string{"b"}})
+	merged := []tagged{{key: 3, tags: []string{"c"}}}
+	tree.Merge(SliceSource(merged))
+	merged[0].tags[0] = "changed"
+	out := tree.InOrderTraverse()
+	assert(len(out), 3, "all values should be added", t)
+	assert(out[2].tags[0], "c", "merged values should be copied", t)
+	assertBalanced(tree, "tree with a clone func", t)
+
+	union, _ := tree.Union(NewAvlTreeFunc(tree.less).Source())
+	tree.root.value.tags[0] = "changed"
+	assert(union.root.value.tags[0] != "changed", true, "Union should copy values into the new tree", t)
+	assert(union.clone != nil, true, "Union should keep the clone func", t)
+
+	// A clone func for another element type is refused
+	assert(panics(func() { NewAvlTree[int](WithCloneFunc(clone)) }), true,
+		"NewAvlTree() with a clone func of the wrong type panics", t)
+}
+
+// Test that values handed out by Graft, ChangeLog and AsOf are copies
+func TestWithCloneFuncExceptions(t *testing.T) {
+	clone := func(v tagged) tagged {
+		v.tags = slices.Clone(v.tags)
+		return v
+	}
+	less := func(a, b tagged) bool { return a.key < b.key }
+	tree := NewAvlTreeFunc(less, WithCloneFunc(clone))
+	since := tree.EnableChangeLog()
+	tree.Add(tagged{key: 1, tags: []string{"a"}})
+
+	other := NewAvlTreeFunc(less)
+	in := tagged{key: 2, tags: []string{"b"}}
+	other.Add(in)
+	tree.Graft(other)
+	in.tags[0] = "changed"
+	assert(tree.maxNode().value.tags[0], "b", "grafted values should be copied", t)
+
+	records, _ := tree.ChangeLog(since)
+	assert(len(records), 2, "len(tree.ChangeLog(since))", t)
+	records[0].Value.tags[0] = "changed"
+	assert(tree.minNode().value.tags[0], "a", "values in change records should be copies", t)
+
+	view, _ := tree.AsOf(tree.Version())
+	for v := range view.All() {
+		v.tags[0] = "changed"
+	}
+	assert(view.InOrderTraverse()[0].tags[0], "a", "values read from AsOf should be copies", t)
+}
@@ -0,0 +1,210 @@
+// Command avldiff compares two persisted trees and prints the values added
+// and removed between them, whether their shapes differ, and how their
+// statistics changed.
+//
+// Each file holds the tree's values in the order they were added, either as
+// a JSON array, as NDJSON (one JSON value per line), or with -gob as a
+// gob-encoded slice. Replaying the values in that order rebuilds the tree's
+// shape as well as its contents.
+//
+// Usage:
+//
+//	avldiff [-type int|float|string] [-gob] old new
+package main
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	avl "github.com/al-ce/go-avltree"
+	"golang.org/x/exp/constraints"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "avldiff:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("avldiff", flag.ContinueOnError)
+	valueType := flags.String("type", "int", "type of the values: int, float or string")
+	useGob := flags.Bool("gob", false, "read gob-encoded slices instead of JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("expected two files to compare")
+	}
+	oldPath, newPath := flags.Arg(0), flags.Arg(1)
+
+	switch *valueType {
+	case "int":
+		return compareFiles[int](w, oldPath, newPath, *useGob)
+	case "float":
+		return compareFiles[float64](w, oldPath, newPath, *useGob)
+	case "string":
+		return compareFiles[string](w, oldPath, newPath, *useGob)
+	}
+	return fmt.Errorf("unknown value type %q", *valueType)
+}
+
+func compareFiles[T constraints.Ordered](w io.Writer, oldPath, newPath string, useGob bool) error {
+	oldTree, err := loadFile[T](oldPath, useGob)
+	if err != nil {
+		return err
+	}
+	newTree, err := loadFile[T](newPath, useGob)
+	if err != nil {
+		return err
+	}
+	return diff(w, oldTree, newTree)
+}
+
+func loadFile[T constraints.Ordered](path string, useGob bool) (*avl.AvlTree[T], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tree, err := load[T](bufio.NewReader(f), useGob)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tree, nil
+}
+
+// Build a tree by adding the values read from r in order
+func load[T constraints.Ordered](r *bufio.Reader, useGob bool) (*avl.AvlTree[T], error) {
+	tree := avl.NewAvlTree[T]()
+	if useGob {
+		var values []T
+		if err := gob.NewDecoder(r).Decode(&values); err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			tree.Add(v)
+		}
+		return tree, nil
+	}
+
+	// A JSON array is read value by value like NDJSON, between its brackets
+	isArray, err := startsWith(r, '[')
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(r)
+	if isArray {
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+	for decoder.More() {
+		var v T
+		if err := decoder.Decode(&v); err != nil {
+			return nil, err
+		}
+		tree.Add(v)
+	}
+	if isArray {
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+	return tree, nil
+}
+
+// Returns whether the first byte after any leading whitespace is b, without
+// consuming it. An empty input starts with nothing.
+func startsWith(r *bufio.Reader, b byte) (bool, error) {
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(c)) {
+			return c == b, r.UnreadByte()
+		}
+	}
+}
+
+// Write the differences between the trees to w: the values only in one of
+// them, whether their structures differ, and their statistics
+func diff[T constraints.Ordered](w io.Writer, oldTree, newTree *avl.AvlTree[T]) error {
+	out := bufio.NewWriter(w)
+
+	// Merge the sorted values, pairing off equal values so that duplicates
+	// are counted
+	oldValues, newValues := oldTree.InOrderTraverse(), newTree.InOrderTraverse()
+	added, removed := 0, 0
+	for i, j := 0, 0; i < len(oldValues) || j < len(newValues); {
+		switch {
+		case j == len(newValues) || (i < len(oldValues) && oldValues[i] < newValues[j]):
+			fmt.Fprintf(out, "- %v\n", oldValues[i])
+			removed += 1
+			i += 1
+		case i == len(oldValues) || newValues[j] < oldValues[i]:
+			fmt.Fprintf(out, "+ %v\n", newValues[j])
+			added += 1
+			j += 1
+		default:
+			i, j = i+1, j+1
+		}
+	}
+	fmt.Fprintf(out, "values: %d added, %d removed\n", added, removed)
+
+	oldShape, newShape := structure(oldTree), structure(newTree)
+	if slices.Equal(oldShape, newShape) {
+		fmt.Fprintln(out, "shape: identical")
+	} else {
+		fmt.Fprintln(out, "shape: differs")
+		for i := range max(len(oldShape), len(newShape)) {
+			oldLine, newLine := lineAt(oldShape, i), lineAt(newShape, i)
+			if oldLine != newLine {
+				fmt.Fprintf(out, "  line %d:\n    - %s\n    + %s\n", i+1, oldLine, newLine)
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "size: %d -> %d (%+d)\n", oldTree.Len(), newTree.Len(), newTree.Len()-oldTree.Len())
+	fmt.Fprintf(out, "height: %d -> %d (%+d)\n", oldTree.Height(), newTree.Height(), newTree.Height()-oldTree.Height())
+	fmt.Fprintf(out, "min: %s -> %s\n", describe(oldTree.Min()), describe(newTree.Min()))
+	fmt.Fprintf(out, "max: %s -> %s\n", describe(oldTree.Max()), describe(newTree.Max()))
+	return out.Flush()
+}
+
+// Returns the lines of the tree's structure as printed by Print
+func structure[T constraints.Ordered](tree *avl.AvlTree[T]) []string {
+	var b strings.Builder
+	tree.Print(&b, avl.WithFormat(avl.PrintStructure))
+	if b.Len() == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+}
+
+func lineAt(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return "(none)"
+}
+
+func describe[T constraints.Ordered](value T, err error) string {
+	if err != nil {
+		return "(empty)"
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Test the report for a JSON array against NDJSON holding the same values
+// in a different order, plus and minus a few
+func TestDiffJSON(t *testing.T) {
+	oldPath := writeFile(t, "old.json", []byte("[1, 2, 3, 3, 5]\n"))
+	newPath := writeFile(t, "new.ndjson", []byte("5\n3\n2\n6\n7\n"))
+	var out bytes.Buffer
+	if err := run([]string{oldPath, newPath}, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `- 1
+- 3
++ 6
++ 7
+values: 2 added, 2 removed
+shape: differs
+  line 1:
+    - 2
+    + 3
+  line 2:
+    - ├── L: 1
+    + ├── L: 2
+  line 3:
+    - └── R: 3
+    + └── R: 6
+  line 4:
+    -     ├── L: 3
+    +     ├── L: 5
+  line 5:
+    -     └── R: 5
+    +     └── R: 7
+size: 5 -> 5 (+0)
+height: 2 -> 2 (+0)
+min: 1 -> 2
+max: 5 -> 7
+`
+	if out.String() != expected {
+		t.Errorf("diff output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+// Test that identical gob-encoded trees report no differences
+func TestDiffGob(t *testing.T) {
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode([]string{"b", "a", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	path := writeFile(t, "tree.gob", data.Bytes())
+	var out bytes.Buffer
+	if err := run([]string{"-type", "string", "-gob", path, path}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "values: 0 added, 0 removed\nshape: identical\n") {
+		t.Errorf("diff output:\n%s", out.String())
+	}
+}
+
+// Test that an empty file is an empty tree and malformed input is an error
+func TestDiffInputs(t *testing.T) {
+	empty := writeFile(t, "empty.json", nil)
+	floats := writeFile(t, "floats.json", []byte("[1.5]"))
+	var out bytes.Buffer
+	if err := run([]string{"-type", "float", empty, floats}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "min: (empty) -> 1.5\n") {
+		t.Errorf("diff output:\n%s", out.String())
+	}
+
+	malformed := writeFile(t, "malformed.json", []byte("[1, \"two\"]"))
+	if err := run([]string{empty, malformed}, &out); err == nil {
+		t.Error("expected an error for malformed input")
+	}
+	if err := run([]string{"-type", "complex", empty, empty}, &out); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+	if err := run([]string{empty}, &out); err == nil {
+		t.Error("expected an error for a missing file argument")
+	}
+}
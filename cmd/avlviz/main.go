@@ -0,0 +1,300 @@
+// Command avlviz is an interactive tree visualizer. It reads commands from
+// standard input, applies them to a tree and redraws the tree's structure
+// after each step, highlighting the nodes that rebalancing moved.
+//
+// Commands:
+//
+//	add v...      add each value, redrawing after each one
+//	remove v...   remove each value, redrawing after each one
+//	find v...     report whether each value is in the tree
+//	print         redraw the tree
+//	clear         remove every value
+//	export [file] write the session so far as a Go test, to the file or
+//	              to standard output
+//	help          list the commands
+//	quit          exit
+//
+// Usage:
+//
+//	avlviz [-type int|float|string] [-plain]
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	avl "github.com/al-ce/go-avltree"
+	"golang.org/x/exp/constraints"
+)
+
+const help = `commands:
+  add v...      add each value, redrawing after each one
+  remove v...   remove each value, redrawing after each one
+  find v...     report whether each value is in the tree
+  print         redraw the tree
+  clear         remove every value
+  export [file] write the session so far as a Go test
+  help          list the commands
+  quit          exit
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "avlviz:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("avlviz", flag.ContinueOnError)
+	valueType := flags.String("type", "int", "type of the values: int, float or string")
+	plain := flags.Bool("plain", false, "mark moved nodes with * instead of color")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("unexpected arguments")
+	}
+
+	switch *valueType {
+	case "int":
+		return newSession(strconv.Atoi, out, *plain).serve(in)
+	case "float":
+		parse := func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+		return newSession(parse, out, *plain).serve(in)
+	case "string":
+		parse := func(s string) (string, error) { return s, nil }
+		return newSession(parse, out, *plain).serve(in)
+	}
+	return fmt.Errorf("unknown value type %q", *valueType)
+}
+
+// A session applies commands to one tree and draws it
+type session[T constraints.Ordered] struct {
+	tree  *avl.AvlTree[T]
+	parse func(string) (T, error)
+	out   io.Writer
+	plain bool
+	// The add, remove and clear steps applied so far, as Go statements
+	steps []string
+	// Positions of the values as last drawn, as paths of L and R from the
+	// root, to find the nodes that moved since
+	positions map[string]map[string]bool
+}
+
+func newSession[T constraints.Ordered](parse func(string) (T, error), out io.Writer, plain bool) *session[T] {
+	return &session[T]{
+		tree:      avl.NewAvlTree[T](),
+		parse:     parse,
+		out:       out,
+		plain:     plain,
+		positions: make(map[string]map[string]bool),
+	}
+}
+
+// Read and run commands until the input ends or a quit command
+func (s *session[T]) serve(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(s.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(s.out)
+			return scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		if err := s.execute(fields[0], fields[1:]); err != nil {
+			fmt.Fprintln(s.out, "error:", err)
+		}
+	}
+}
+
+func (s *session[T]) execute(command string, args []string) error {
+	if command == "export" {
+		return s.export(args)
+	}
+	values := make([]T, len(args))
+	for i, arg := range args {
+		v, err := s.parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid value %q", arg)
+		}
+		values[i] = v
+	}
+
+	switch command {
+	case "add":
+		for _, v := range values {
+			s.tree.Add(v)
+			s.steps = append(s.steps, fmt.Sprintf("tree.Add(%#v)", v))
+			fmt.Fprintf(s.out, "add %v\n", v)
+			s.draw()
+		}
+	case "remove":
+		for _, v := range values {
+			s.steps = append(s.steps, fmt.Sprintf("tree.Remove(%#v)", v))
+			if !s.tree.Remove(v) {
+				fmt.Fprintf(s.out, "remove %v: not found\n", v)
+				continue
+			}
+			fmt.Fprintf(s.out, "remove %v\n", v)
+			s.draw()
+		}
+	case "find":
+		for _, v := range values {
+			if s.tree.Contains(v) {
+				fmt.Fprintf(s.out, "find %v: found\n", v)
+			} else {
+				fmt.Fprintf(s.out, "find %v: not found\n", v)
+			}
+		}
+	case "print":
+		s.draw()
+	case "clear":
+		s.tree.Clear()
+		s.steps = append(s.steps, "tree.Clear()")
+		s.draw()
+	case "help":
+		fmt.Fprint(s.out, help)
+	default:
+		return fmt.Errorf("unknown command %q, try help", command)
+	}
+	return nil
+}
+
+// Write the session's steps as a Go test that replays them and checks the
+// resulting structure, to the file named in args or to the output
+func (s *session[T]) export(args []string) error {
+	if len(args) > 1 {
+		return errors.New("export takes at most one file name")
+	}
+	var b strings.Builder
+	s.tree.Print(&b, avl.WithFormat(avl.PrintStructure))
+	expected := "`" + b.String() + "`"
+	if strings.Contains(b.String(), "`") {
+		expected = strconv.Quote(b.String())
+	}
+	var zero T
+	test := fmt.Sprintf(testTemplate, zero, strings.Join(s.steps, "\n\t"), expected)
+
+	if len(args) == 0 {
+		fmt.Fprint(s.out, test)
+		return nil
+	}
+	if err := os.WriteFile(args[0], []byte(test), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "exported %d steps to %s\n", len(s.steps), args[0])
+	return nil
+}
+
+// A test replaying a session, given the value type, the steps and the
+// expected structure as a Go string literal
+const testTemplate = `package avl_test
+
+import (
+	"strings"
+	"testing"
+
+	avl "github.com/al-ce/go-avltree"
+)
+
+// Replays a session recorded with avlviz
+func TestAvlvizSession(t *testing.T) {
+	tree := avl.NewAvlTree[%T]()
+	%s
+
+	var b strings.Builder
+	tree.Print(&b, avl.WithFormat(avl.PrintStructure))
+	expected := %s
+	if b.String() != expected {
+		t.Errorf("tree structure:\n%%s\nexpected:\n%%s", b.String(), expected)
+	}
+}
+`
+
+// Draw the tree's structure with its height and balance factors, marking
+// the nodes that were already drawn elsewhere: the ones rebalancing moved
+func (s *session[T]) draw() {
+	var b strings.Builder
+	s.tree.Print(&b, avl.WithFormat(avl.PrintBalance))
+	if b.Len() == 0 {
+		fmt.Fprintln(s.out, "(empty)")
+		s.positions = make(map[string]map[string]bool)
+		return
+	}
+
+	positions := make(map[string]map[string]bool)
+	moved := 0
+	for _, line := range parseStructure(b.String()) {
+		if positions[line.value] == nil {
+			positions[line.value] = make(map[string]bool)
+		}
+		positions[line.value][line.path] = true
+
+		old := s.positions[line.value]
+		if old != nil && !old[line.path] {
+			moved += 1
+			if s.plain {
+				line.text += " *"
+			} else {
+				line.text = "\x1b[1;33m" + line.text + "\x1b[0m"
+			}
+		}
+		fmt.Fprintln(s.out, line.text)
+	}
+	s.positions = positions
+	if moved > 0 {
+		fmt.Fprintf(s.out, "rebalanced: %d moved\n", moved)
+	}
+}
+
+// A line of the tree's structure as printed by Print
+type structureLine struct {
+	text string
+	// The node's value as printed, without annotations
+	value string
+	// The node's position, as the sides taken from the root
+	path string
+}
+
+// Split the structure printed by Print into lines, recovering the position
+// of each node from the indentation
+func parseStructure(printed string) []structureLine {
+	lines := make([]structureLine, 0)
+	paths := []string{""}
+	for _, text := range strings.Split(strings.TrimSuffix(printed, "\n"), "\n") {
+		runes := []rune(text)
+		// Each level below the root is indented by four runes, and the last
+		// one starts with a branch and the side, e.g. "├── L: "
+		depth, path := 0, ""
+		for i := 0; i+7 <= len(runes); i += 4 {
+			if runes[i] != '├' && runes[i] != '└' {
+				continue
+			}
+			depth = i/4 + 1
+			path = paths[depth-1] + string(runes[i+4])
+			runes = runes[i+7:]
+			break
+		}
+		paths = append(paths[:depth], path)
+
+		value := string(runes)
+		if i := strings.LastIndex(value, " (h="); i >= 0 {
+			value = value[:i]
+		}
+		lines = append(lines, structureLine{text: text, value: value, path: path})
+	}
+	return lines
+}
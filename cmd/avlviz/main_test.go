@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test a session that triggers a rotation, with moved nodes marked
+func TestSession(t *testing.T) {
+	in := strings.NewReader("add 1 2 3\nfind 2 9\nremove 7\nbogus\n\nquit\nadd 4\n")
+	var out bytes.Buffer
+	if err := run([]string{"-plain"}, in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `> add 1
+1 (h=0 bf=+0)
+add 2
+1 (h=1 bf=+1)
+└── R: 2 (h=0 bf=+0)
+add 3
+2 (h=1 bf=+0) *
+├── L: 1 (h=0 bf=+0) *
+└── R: 3 (h=0 bf=+0)
+rebalanced: 2 moved
+> find 2: found
+find 9: not found
+> remove 7: not found
+> error: unknown command "bogus", try help
+> > `
+	if out.String() != expected {
+		t.Errorf("session output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+// Test that node positions are recovered from the printed structure
+func TestParseStructure(t *testing.T) {
+	printed := "4\n├── L: 2\n│   ├── L: 1\n│   └── R: 3\n└── R: 6\n    └── L: 5\n"
+	expected := []structureLine{
+		{"4", "4", ""},
+		{"├── L: 2", "2", "L"},
+		{"│   ├── L: 1", "1", "LL"},
+		{"│   └── R: 3", "3", "LR"},
+		{"└── R: 6", "6", "R"},
+		{"    └── L: 5", "5", "RL"},
+	}
+	lines := parseStructure(printed)
+	if len(lines) != len(expected) {
+		t.Fatalf("parsed %d lines, expected %d", len(lines), len(expected))
+	}
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("line %d parsed as %+v, expected %+v", i, line, expected[i])
+		}
+	}
+}
+
+// Test that invalid values and flags are reported
+func TestSessionErrors(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-type", "float", "-plain"}, strings.NewReader("add x\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `error: invalid value "x"`) {
+		t.Errorf("session output:\n%s", out.String())
+	}
+	if err := run([]string{"-type", "complex"}, strings.NewReader(""), &out); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
+
+// Test that an exported session replays its steps and checks the structure
+func TestExport(t *testing.T) {
+	in := strings.NewReader("add b a c\nremove a z\nexport\n")
+	var out bytes.Buffer
+	if err := run([]string{"-type", "string", "-plain"}, in, &out); err != nil {
+		t.Fatal(err)
+	}
+	_, exported, _ := strings.Cut(out.String(), "> package")
+	for _, expected := range []string{
+		"tree := avl.NewAvlTree[string]()\n",
+		"\ttree.Add(\"b\")\n\ttree.Add(\"a\")\n\ttree.Add(\"c\")\n\ttree.Remove(\"a\")\n\ttree.Remove(\"z\")\n",
+		"expected := `b\n└── R: c\n`\n",
+	} {
+		if !strings.Contains(exported, expected) {
+			t.Errorf("exported test doesn't contain %q:\n%s", expected, exported)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "session_test.go")
+	out.Reset()
+	if err := run([]string{"-plain"}, strings.NewReader("add 1\nexport "+path+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\ttree.Add(1)\n") {
+		t.Errorf("exported file:\n%s", data)
+	}
+}
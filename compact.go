@@ -0,0 +1,263 @@
+package avl
+
+import "golang.org/x/exp/constraints"
+
+// CompactAvlTree is an AVL tree for memory-constrained deployments. Its nodes
+// hold only a value, a balance factor and two children: no parent pointer and
+// no subtree size, which saves 16 bytes per node over AvlTree and the cost of
+// keeping parent links right through rotations. Insertion and removal
+// rebalance on the way back up the search path instead of following parents.
+//
+// Equal values are ordered as in AvlTree. Operations that need parent links
+// or subtree sizes (ranks, node handles, change logs) are only available on
+// AvlTree.
+type CompactAvlTree[T constraints.Ordered] struct {
+	root *compactNode[T]
+	size int
+}
+
+type compactNode[T constraints.Ordered] struct {
+	value    T
+	balance  int8
+	children [2]*compactNode[T]
+}
+
+// %% Public methods %%
+
+func NewCompactAvlTree[T constraints.Ordered]() *CompactAvlTree[T] {
+	return &CompactAvlTree[T]{}
+}
+
+// Insert a node with the given value and rebalance the tree. Duplicate values
+// are kept, after any equal values already in the tree.
+func (tree *CompactAvlTree[T]) Add(value T) {
+	tree.root, _ = tree.root.insert(value)
+	tree.size += 1
+}
+
+// Remove a node by value lookup and rebalance the tree.
+// Returns true on successful removal, false if value was not found.
+func (tree *CompactAvlTree[T]) Remove(value T) bool {
+	var removed bool
+	tree.root, removed, _ = tree.root.remove(value)
+	if removed {
+		tree.size -= 1
+	}
+	return removed
+}
+
+// Returns a bool indicating whether the value exists in the tree
+func (tree *CompactAvlTree[T]) Contains(value T) bool {
+	curr := tree.root
+	for curr != nil && curr.value != value {
+		if value < curr.value {
+			curr = curr.children[dirLeft]
+		} else {
+			curr = curr.children[dirRight]
+		}
+	}
+	return curr != nil
+}
+
+// Clear the tree, removing all nodes
+func (tree *CompactAvlTree[T]) Clear() {
+	tree.root = nil
+	tree.size = 0
+}
+
+// Return the number of nodes in the tree
+func (tree *CompactAvlTree[T]) Len() int {
+	return tree.size
+}
+
+// Return the height of the tree, or -1 for an empty tree. See AvlTree.Height.
+func (tree *CompactAvlTree[T]) Height() int {
+	height := -1
+	for curr := tree.root; curr != nil; height += 1 {
+		if curr.balance < 0 {
+			curr = curr.children[dirLeft]
+		} else {
+			curr = curr.children[dirRight]
+		}
+	}
+	return height
+}
+
+// Return the minimum value in the tree
+func (tree *CompactAvlTree[T]) Min() (T, error) {
+	return tree.edgeValue(dirLeft)
+}
+
+// Return the maximum value in the tree
+func (tree *CompactAvlTree[T]) Max() (T, error) {
+	return tree.edgeValue(dirRight)
+}
+
+// Returns a slice of the tree's values in-order
+func (tree *CompactAvlTree[T]) InOrderTraverse() []T {
+	values := make([]T, 0, tree.size)
+	stack := make([]*compactNode[T], 0, tree.Height()+1)
+	curr := tree.root
+	for curr != nil || len(stack) > 0 {
+		for ; curr != nil; curr = curr.children[dirLeft] {
+			stack = append(stack, curr)
+		}
+		curr = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		values = append(values, curr.value)
+		curr = curr.children[dirRight]
+	}
+	return values
+}
+
+// %%% Tree private methods %%%
+
+func (tree *CompactAvlTree[T]) edgeValue(dir direction) (T, error) {
+	curr := tree.root
+	if curr == nil {
+		var zero T
+		return zero, ErrEmptyTree
+	}
+	for curr.children[dir] != nil {
+		curr = curr.children[dir]
+	}
+	return curr.value, nil
+}
+
+// %%% Node private methods %%%
+
+// Insert the value into the subtree rooted at the node. Returns the new root
+// of the subtree and whether the subtree grew.
+func (node *compactNode[T]) insert(value T) (*compactNode[T], bool) {
+	if node == nil {
+		return &compactNode[T]{value: value}, true
+	}
+	// Equal values descend right so they end up after existing ones
+	dir := dirRight
+	if value < node.value {
+		dir = dirLeft
+	}
+	var grew bool
+	node.children[dir], grew = node.children[dir].insert(value)
+	if !grew {
+		return node, false
+	}
+
+	node.balance += int8(dir.sign())
+	switch node.balance {
+	case 0:
+		return node, false
+	case -1, 1:
+		return node, true
+	}
+	// A rotation after an insertion restores the subtree's previous height
+	return node.rebalance(), false
+}
+
+// Remove a node holding the value from the subtree rooted at the node.
+// Returns the new root of the subtree, whether a node was removed and whether
+// the subtree shrank.
+func (node *compactNode[T]) remove(value T) (*compactNode[T], bool, bool) {
+	if node == nil {
+		return nil, false, false
+	}
+	if value == node.value {
+		root, shrunk := node.unlink()
+		return root, true, shrunk
+	}
+
+	dir := dirRight
+	if value < node.value {
+		dir = dirLeft
+	}
+	var removed, shrunk bool
+	node.children[dir], removed, shrunk = node.children[dir].remove(value)
+	if !shrunk {
+		return node, removed, false
+	}
+	node, shrunk = node.shrink(dir)
+	return node, removed, shrunk
+}
+
+// Remove the node itself from its subtree. Returns the new root of the
+// subtree and whether the subtree shrank.
+func (node *compactNode[T]) unlink() (*compactNode[T], bool) {
+	if node.children[dirLeft] == nil {
+		return node.children[dirRight], true
+	}
+	if node.children[dirRight] == nil {
+		return node.children[dirLeft], true
+	}
+
+	// Two children: the in-order successor takes the node's place
+	right, successor, shrunk := node.children[dirRight].removeMin()
+	successor.children = [2]*compactNode[T]{node.children[dirLeft], right}
+	successor.balance = node.balance
+	if !shrunk {
+		return successor, false
+	}
+	return successor.shrink(dirRight)
+}
+
+// Remove the leftmost node of the subtree rooted at the node. Returns the new
+// root of the subtree, the removed node and whether the subtree shrank.
+func (node *compactNode[T]) removeMin() (*compactNode[T], *compactNode[T], bool) {
+	if node.children[dirLeft] == nil {
+		return node.children[dirRight], node, true
+	}
+	var min *compactNode[T]
+	var shrunk bool
+	node.children[dirLeft], min, shrunk = node.children[dirLeft].removeMin()
+	if !shrunk {
+		return node, min, false
+	}
+	node, shrunk = node.shrink(dirLeft)
+	return node, min, shrunk
+}
+
+// Update the balance factor after the subtree in the given direction lost a
+// level, rotating if needed. Returns the new root of the subtree and whether
+// the subtree shrank.
+func (node *compactNode[T]) shrink(dir direction) (*compactNode[T], bool) {
+	node.balance -= int8(dir.sign())
+	switch node.balance {
+	case 0:
+		return node, true
+	case -1, 1:
+		return node, false // The other subtree still holds the height
+	}
+	node = node.rebalance()
+	return node, node.balance == 0
+}
+
+// Rotate an unbalanced node to restore the balance of its subtree. Returns
+// the new root of the subtree.
+func (node *compactNode[T]) rebalance() *compactNode[T] {
+	heavy := dirRight
+	if node.balance < 0 {
+		heavy = dirLeft
+	}
+	// A child leaning the other way is rotated first (double rotation)
+	if child := node.children[heavy]; int(child.balance)*heavy.sign() < 0 {
+		node.children[heavy] = child.rotate(heavy)
+	}
+	return node.rotate(heavy.opposite())
+}
+
+// Rotate the node in the given direction, moving its child on the opposite
+// side into its place. Returns the new root of the subtree. Balance factors
+// are updated as in Node.rotate.
+func (node *compactNode[T]) rotate(dir direction) *compactNode[T] {
+	up := dir.opposite()
+	child := node.children[up]
+	node.children[up] = child.children[dir]
+	child.children[dir] = node
+
+	tilt := int(node.balance) * up.sign()
+	childTilt := int(child.balance) * up.sign()
+	tilt = tilt - 1 - max(childTilt, 0)
+	childTilt = childTilt - 1 + min(tilt, 0)
+	node.balance = int8(tilt * up.sign())
+	child.balance = int8(childTilt * up.sign())
+	return child
+}
@@ -0,0 +1,59 @@
+package avl
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Check the balance factors of every node in a compact tree against the
+// measured heights of its subtrees, returning the height of the subtree
+func assertCompactBalanced(node *compactNode[int], msg string, t *testing.T) int {
+	t.Helper()
+	if node == nil {
+		return -1
+	}
+	leftHeight := assertCompactBalanced(node.children[dirLeft], msg, t)
+	rightHeight := assertCompactBalanced(node.children[dirRight], msg, t)
+	if balance := rightHeight - leftHeight; int(node.balance) != balance || balance < -1 || balance > 1 {
+		t.Errorf("%s: node %v has balance %+d, measured %+d", msg, node.value, node.balance, balance)
+	}
+	return max(leftHeight, rightHeight) + 1
+}
+
+// Test that the compact tree holds the same values as an AvlTree under the
+// same random operations, and stays balanced
+func TestCompactAvlTree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	compact, tree := NewCompactAvlTree[int](), NewAvlTree[int]()
+	for i := 0; i < 5000; i++ {
+		value := r.Intn(200)
+		if r.Intn(3) == 0 {
+			assert(compact.Remove(value), tree.Remove(value), "compact.Remove()", t)
+		} else {
+			compact.Add(value)
+			tree.Add(value)
+		}
+		if i%100 == 0 {
+			assertSlice(compact.InOrderTraverse(), tree.InOrderTraverse(), "compact.InOrderTraverse()", t)
+			height := assertCompactBalanced(compact.root, "compact tree", t)
+			assert(compact.Height(), height, "compact.Height()", t)
+		}
+	}
+	assert(compact.Len(), tree.Len(), "compact.Len()", t)
+	assert(compact.Contains(tree.root.value), true, "compact.Contains()", t)
+
+	minVal, err := compact.Min()
+	assert(err, nil, "compact.Min()", t)
+	treeMin, _ := tree.Min()
+	assert(minVal, treeMin, "compact.Min()", t)
+	maxVal, err := compact.Max()
+	assert(err, nil, "compact.Max()", t)
+	treeMax, _ := tree.Max()
+	assert(maxVal, treeMax, "compact.Max()", t)
+
+	compact.Clear()
+	assert(compact.Len(), 0, "compact.Len() after Clear", t)
+	assert(compact.Contains(treeMin), false, "compact.Contains() after Clear", t)
+	_, err = compact.Min()
+	assert(err != nil, true, "compact.Min() on empty tree", t)
+}
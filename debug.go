@@ -0,0 +1,112 @@
+package avl
+
+import (
+	"fmt"
+	"io"
+)
+
+// Write every node of the tree to w in order, one per line, with its value,
+// height, balance factor, subtree size and the values of its parent and
+// children, followed by any violated invariants, for bug reports.
+// Returns the first error encountered writing to w.
+func (tree *AvlTree[T]) DumpState(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("size=%d version=%d root=%s\n", tree.size, tree.version, describeNode(tree.root))
+	if tree.root != nil && tree.root.parent != nil {
+		printf("  !! root has parent %s\n", describeNode(tree.root.parent))
+	}
+	if count := nodeCount(tree.root); count != tree.size {
+		printf("  !! size %d, root count %d\n", tree.size, count)
+	}
+
+	heights := make(map[*Node[T]]int)
+	measureHeights(tree.root, heights)
+	visited := make(map[*Node[T]]bool)
+	var prev *Node[T]
+	var dump func(node *Node[T])
+	dump = func(node *Node[T]) {
+		if node == nil {
+			return
+		}
+		if visited[node] {
+			printf("  !! cycle at %v\n", node.value)
+			return
+		}
+		visited[node] = true
+
+		dump(node.children[dirLeft])
+		printf("value=%v height=%d balance=%+d count=%d parent=%s left=%s right=%s\n",
+			node.value, heights[node], node.balanceFactor(), node.count,
+			describeNode(node.parent), describeNode(node.children[dirLeft]), describeNode(node.children[dirRight]))
+		violations := node.violations(heights)
+		if prev != nil && tree.less(node.value, prev.value) {
+			violations = append(violations, fmt.Sprintf("out of order after %v", prev.value))
+		}
+		for _, v := range violations {
+			printf("  !! %s\n", v)
+		}
+		prev = node
+		dump(node.children[dirRight])
+	}
+	dump(tree.root)
+	return err
+}
+
+// Record the measured height of every node in the subtree, rather than the
+// one implied by the stored balance factors, which may be what's broken.
+// Returns the height of the subtree, or -1 for a nil node.
+func measureHeights[T any](node *Node[T], heights map[*Node[T]]int) int {
+	if node == nil {
+		return -1
+	}
+	if height, ok := heights[node]; ok {
+		return height // Already measured, or part of a cycle
+	}
+	heights[node] = 0
+	height := max(measureHeights(node.children[dirLeft], heights), measureHeights(node.children[dirRight], heights)) + 1
+	heights[node] = height
+	return height
+}
+
+// Returns descriptions of the invariants the node violates with respect to
+// its children, given the measured heights of the subtrees
+func (node *Node[T]) violations(heights map[*Node[T]]int) []string {
+	violations := make([]string, 0)
+	height := func(node *Node[T]) int {
+		if node == nil {
+			return -1
+		}
+		return heights[node]
+	}
+	balance := height(node.children[dirRight]) - height(node.children[dirLeft])
+	if node.balanceFactor() != balance {
+		violations = append(violations, fmt.Sprintf("balance %+d, expected %+d", node.balanceFactor(), balance))
+	}
+	count := nodeCount(node.children[dirLeft]) + nodeCount(node.children[dirRight]) + 1
+	if node.count != count {
+		violations = append(violations, fmt.Sprintf("count %d, expected %d", node.count, count))
+	}
+	if balance < -1 || balance > 1 {
+		violations = append(violations, fmt.Sprintf("unbalanced (%+d)", balance))
+	}
+	for _, child := range node.children {
+		if child != nil && child.parent != node {
+			violations = append(violations, fmt.Sprintf("child %v has parent %s", child.value, describeNode(child.parent)))
+		}
+	}
+	return violations
+}
+
+// Returns the node's value as a string, or "nil"
+func describeNode[T any](node *Node[T]) string {
+	if node == nil {
+		return "nil"
+	}
+	return fmt.Sprint(node.value)
+}
@@ -0,0 +1,41 @@
+package avl
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test the dump of a healthy tree lists every node without violations
+func TestDumpState(t *testing.T) {
+	tree := populateTree(t, []int{2, 1, 3})
+	var out strings.Builder
+	assert(tree.DumpState(&out), nil, "tree.DumpState()", t)
+
+	expected := "size=3 version=3 root=2\n" +
+		"value=1 height=0 balance=+0 count=1 parent=2 left=nil right=nil\n" +
+		"value=2 height=1 balance=+0 count=3 parent=nil left=1 right=3\n" +
+		"value=3 height=0 balance=+0 count=1 parent=2 left=nil right=nil\n"
+	assert(out.String(), expected, "tree.DumpState() output", t)
+}
+
+// Test that corrupted nodes are flagged
+func TestDumpStateViolations(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(1, 7, 1))
+	tree.root.children[dirLeft].value = 100
+	tree.root.children[dirRight].balance = 1
+	tree.root.children[dirRight].children[dirRight].parent = nil
+	tree.root.children[dirRight].children[dirRight].children[dirRight] = newTreeNode(8)
+	tree.root.children[dirRight].children[dirRight].children[dirRight].children[dirRight] = newTreeNode(9)
+
+	var out strings.Builder
+	tree.DumpState(&out)
+	dump := out.String()
+	for _, violation := range []string{
+		"!! out of order after 100",
+		"!! balance +1, expected +2",
+		"!! child 7 has parent nil",
+		"!! unbalanced",
+	} {
+		assert(strings.Contains(dump, violation), true, "tree.DumpState() flags "+violation, t)
+	}
+}
@@ -0,0 +1,143 @@
+package avl
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Remove and return the n smallest values in the tree, in ascending order,
+// or every value if the tree holds fewer than n. The tree is split once at
+// the n-th value in O(log n) rebalancing work, instead of rebalancing after
+// each removal as repeated PopMin calls would, plus O(n) to collect the
+// values. Each removal is recorded in the change log like a Remove.
+func (tree *AvlTree[T]) DrainMin(n int) []T {
+	n = min(max(n, 0), tree.size)
+	if n == 0 {
+		return []T{}
+	}
+	drained, rest, _, restHeight := splitAt(tree.root, n, tree.height)
+	return tree.drain(drained, rest, restHeight, n, 0)
+}
+
+// Remove and return the n largest values in the tree, in descending order as
+// repeated PopMax calls would return them. See DrainMin.
+func (tree *AvlTree[T]) DrainMax(n int) []T {
+	n = min(max(n, 0), tree.size)
+	if n == 0 {
+		return []T{}
+	}
+	rest, drained, restHeight, _ := splitAt(tree.root, tree.size-n, tree.height)
+	values := tree.drain(drained, rest, restHeight, n, tree.size-n)
+	slices.Reverse(values)
+	return values
+}
+
+// Replace the tree with the rest subtree of the given height, returning the
+// values of the n nodes in the drained subtree in-order. The drained values
+// started at the given rank, where each is recorded as removed in turn.
+func (tree *AvlTree[T]) drain(drained, rest *Node[T], restHeight, n, rank int) []T {
+	values := make([]T, 0, n)
+	tree.inOrderTraverseHelper(drained, &values)
+
+	tree.replaceRoot(rest)
+	tree.size -= n
+	tree.height = restHeight
+	tree.resetEdges()
+	for _, v := range values {
+		tree.recordChangeAt(ChangeRemove, rank, v)
+	}
+	return values
+}
+
+// Split the subtree of the given height rooted at the node into the subtrees
+// of its first k nodes in-order and of the remaining nodes, joining the pieces
+// on the way back up the search path for k. The heights of the children on
+// the path follow from the balance factors, and each join takes time
+// proportional to the difference in height of the pieces it joins, which adds
+// up to O(log n) over the path.
+// Returns the roots of both subtrees and their heights.
+func splitAt[T any](node *Node[T], k, height int) (first, rest *Node[T], firstHeight, restHeight int) {
+	if node == nil {
+		return nil, nil, -1, -1
+	}
+	left, right := node.children[dirLeft], node.children[dirRight]
+	heights := [2]int{height - 1, height - 1}
+	if node.balance > 0 {
+		heights[dirLeft] -= int(node.balance)
+	} else {
+		heights[dirRight] += int(node.balance)
+	}
+	if k <= nodeCount(left) {
+		first, rest, firstHeight, restHeight = splitAt(left, k, heights[dirLeft])
+		rest, restHeight = joinNodes(rest, node, right, [2]int{restHeight, heights[dirRight]})
+		return first, rest, firstHeight, restHeight
+	}
+	first, rest, firstHeight, restHeight = splitAt(right, k-nodeCount(left)-1, heights[dirRight])
+	first, firstHeight = joinNodes(left, node, first, [2]int{heights[dirLeft], firstHeight})
+	return first, rest, firstHeight, restHeight
+}
+
+// Join two subtrees of the given heights and a pivot node between them.
+// Returns the new root and its height.
+func joinNodes[T any](left, pivot, right *Node[T], heights [2]int) (*Node[T], int) {
+	joined := newTree[T](nil)
+	joined.joinWith(left, pivot, right, heights)
+	return joined.root, joined.height
+}
+
+// Remove every value greater than or equal to from and less than to, passing
+// each removed value to removed in ascending order, e.g. to release resources
+// the values own. removed may be nil. The range is cut out with two splits
+// and a join, so rebalancing costs O(log n) however many values are removed.
+// Each removal is recorded in the change log like a Remove.
+// Returns the number of values removed, or an error wrapping ErrInvalidRange
+// if to is less than from.
+func (tree *AvlTree[T]) RemoveRange(from, to T, removed func(T)) (int, error) {
+	if tree.less(to, from) {
+		return 0, tree.misuse(fmt.Errorf("%w: from %v is after to %v", ErrInvalidRange, from, to))
+	}
+	lo, hi := tree.CountLess(from), tree.CountLess(to)
+	if lo == hi {
+		return 0, nil
+	}
+
+	rest, upper, restHeight, _ := splitAt(tree.root, hi, tree.height)
+	lower, middle, _, _ := splitAt(rest, lo, restHeight)
+	switch {
+	case lower == nil:
+		tree.replaceRoot(upper)
+	case upper == nil:
+		tree.replaceRoot(lower)
+	default:
+		tree.join(lower, upper)
+	}
+	tree.size -= hi - lo
+	tree.height = subtreeHeight(tree.root)
+	tree.resetEdges()
+
+	for node := middle; node != nil; node = node.children[dirLeft] {
+		middle = node
+	}
+	for node := middle; node != nil; node = nextNode(node) {
+		tree.recordChangeAt(ChangeRemove, lo, node.value)
+		if removed != nil {
+			removed(node.value)
+		}
+	}
+	return hi - lo, nil
+}
+
+// Remove every value satisfying pred, passing each removed value to removed
+// in ascending order. removed may be nil. See ExtractIf for removing values
+// lazily as they are consumed.
+// Returns the number of values removed.
+func (tree *AvlTree[T]) RemoveIf(pred func(T) bool, removed func(T)) int {
+	count := 0
+	for v := range tree.ExtractIf(pred) {
+		count += 1
+		if removed != nil {
+			removed(v)
+		}
+	}
+	return count
+}
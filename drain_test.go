@@ -0,0 +1,103 @@
+package avl
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Test that draining returns the same values as repeated pops and leaves a
+// balanced tree
+func TestDrainMinMax(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{0, 1, 2, 7, 100} {
+		for _, n := range []int{-1, 0, 1, size / 3, size - 1, size, size + 5} {
+			values := make([]int, size)
+			for i := range values {
+				values[i] = r.Intn(50)
+			}
+			msg := fmt.Sprintf("%d of %d values", n, size)
+
+			tree, popped := populateTree(t, values), populateTree(t, values)
+			expected := make([]int, 0)
+			for i := 0; i < n; i++ {
+				if v, err := popped.PopMin(); err == nil {
+					expected = append(expected, v)
+				}
+			}
+			assertSlice(tree.DrainMin(n), expected, "tree.DrainMin() "+msg, t)
+			assertSlice(tree.InOrderTraverse(), popped.InOrderTraverse(), "tree after DrainMin() "+msg, t)
+			assertBalanced(tree, "tree after DrainMin() "+msg, t)
+
+			tree, popped = populateTree(t, values), populateTree(t, values)
+			expected = make([]int, 0)
+			for i := 0; i < n; i++ {
+				if v, err := popped.PopMax(); err == nil {
+					expected = append(expected, v)
+				}
+			}
+			assertSlice(tree.DrainMax(n), expected, "tree.DrainMax() "+msg, t)
+			assertSlice(tree.InOrderTraverse(), popped.InOrderTraverse(), "tree after DrainMax() "+msg, t)
+			assertBalanced(tree, "tree after DrainMax() "+msg, t)
+		}
+	}
+}
+
+// Test that drained values are recorded as removals
+func TestDrainChangeLog(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(1, 10, 1))
+	since := tree.EnableChangeLog()
+	follower := populateTree(t, rangeWithSteps(1, 10, 1))
+
+	tree.DrainMin(3)
+	tree.DrainMax(2)
+	assert(tree.Version(), since+5, "tree.Version() after draining", t)
+	follower.ApplyChanges(changesSince(t, tree, since))
+	assertSlice(follower.InOrderTraverse(), []int{4, 5, 6, 7, 8}, "follower after draining", t)
+}
+
+// Test that range removal passes the removed values on and keeps the tree
+// balanced, for ranges covering every part of the tree
+func TestRemoveRange(t *testing.T) {
+	for from := -1; from <= 21; from += 3 {
+		for to := from; to <= 22; to += 4 {
+			tree := populateTree(t, rangeWithSteps(0, 20, 1))
+			tree.Add(10)
+			removed := make([]int, 0)
+			count, err := tree.RemoveRange(from, to, func(v int) { removed = append(removed, v) })
+			msg := fmt.Sprintf("tree.RemoveRange(%d, %d)", from, to)
+			assert(err, nil, msg, t)
+
+			expected, kept := make([]int, 0), make([]int, 0)
+			for _, v := range append(rangeWithSteps(0, 10, 1), rangeWithSteps(10, 20, 1)...) {
+				if v >= from && v < to {
+					expected = append(expected, v)
+				} else {
+					kept = append(kept, v)
+				}
+			}
+			assert(count, len(expected), msg+" count", t)
+			assertSlice(removed, expected, msg+" removed values", t)
+			assertSlice(tree.InOrderTraverse(), kept, msg+" remaining values", t)
+			assertBalanced(tree, msg, t)
+		}
+	}
+
+	tree := populateTree(t, []int{1, 2, 3})
+	_, err := tree.RemoveRange(3, 1, nil)
+	assert(errors.Is(err, ErrInvalidRange), true, "tree.RemoveRange() with reversed bounds", t)
+	count, _ := tree.RemoveRange(2, 3, nil)
+	assert(count, 1, "tree.RemoveRange() without callback", t)
+}
+
+// Test that RemoveIf passes the removed values on
+func TestRemoveIf(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(1, 10, 1))
+	removed := make([]int, 0)
+	count := tree.RemoveIf(func(v int) bool { return v%4 == 0 }, func(v int) { removed = append(removed, v) })
+	assert(count, 2, "tree.RemoveIf() count", t)
+	assertSlice(removed, []int{4, 8}, "tree.RemoveIf() removed values", t)
+	assert(tree.RemoveIf(func(v int) bool { return v > 8 }, nil), 2, "tree.RemoveIf() without callback", t)
+	assertSlice(tree.InOrderTraverse(), []int{1, 2, 3, 5, 6, 7}, "tree after RemoveIf()", t)
+}
@@ -0,0 +1,98 @@
+package avl
+
+import (
+	"context"
+	"time"
+)
+
+// DueQueue holds values scheduled for a point in time and hands them back
+// once that time has passed, as a building block for pollers and retry loops.
+// Scheduled values are kept in an AvlTree ordered by time, so values are
+// drained in deadline order, and values sharing a deadline in the order they
+// were scheduled. Any time.Time can be a deadline.
+type DueQueue[V any] struct {
+	scheduled *AvlTree[dueEntry[V]]
+	clock     Clock
+}
+
+type dueEntry[V any] struct {
+	deadline time.Time
+	value    V
+}
+
+// Returns a new, empty due queue
+func NewDueQueue[V any]() *DueQueue[V] {
+	return &DueQueue[V]{
+		scheduled: NewAvlTreeFunc(func(a, b dueEntry[V]) bool { return a.deadline.Before(b.deadline) }),
+		clock:     SystemClock(),
+	}
+}
+
+// Set the clock the queue reads the current time from in ScheduleAfter and
+// Wait, such as a fake clock in tests. The default is SystemClock.
+func (queue *DueQueue[V]) SetClock(clock Clock) {
+	queue.clock = clock
+}
+
+// Schedule a value to become due at the given time. Deadlines are compared
+// by wall clock, so any monotonic clock reading in t is dropped.
+func (queue *DueQueue[V]) Schedule(t time.Time, value V) {
+	queue.scheduled.Add(dueEntry[V]{deadline: t.Round(0), value: value})
+}
+
+// Schedule a value to become due once d has passed on the queue's clock
+func (queue *DueQueue[V]) ScheduleAfter(d time.Duration, value V) {
+	queue.Schedule(queue.clock.Now().Add(d), value)
+}
+
+// Remove and return every value due at or before now, in deadline order
+func (queue *DueQueue[V]) DrainDue(now time.Time) []V {
+	due := make([]V, 0)
+	for !queue.scheduled.IsEmpty() {
+		entry, _ := queue.scheduled.GetMin()
+		if entry.deadline.After(now) {
+			break
+		}
+		queue.scheduled.PopMin()
+		due = append(due, entry.value)
+	}
+	return due
+}
+
+// Returns the earliest scheduled deadline, or false if the queue is empty.
+// The returned time is as scheduled, with no monotonic reading.
+func (queue *DueQueue[V]) NextDeadline() (time.Time, bool) {
+	entry, err := queue.scheduled.GetMin()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return entry.deadline, true
+}
+
+// Return the number of scheduled values
+func (queue *DueQueue[V]) Len() int {
+	return queue.scheduled.Len()
+}
+
+// Block until the earliest deadline has passed on the queue's clock, then
+// remove and return every value due, as with DrainDue. Values scheduled while
+// waiting are not noticed until the wait ends, so the queue must not be used
+// from other goroutines in the meantime.
+// Returns ErrEmptyTree at once if nothing is scheduled, or the context's
+// error if it is done first.
+func (queue *DueQueue[V]) Wait(ctx context.Context) ([]V, error) {
+	next, ok := queue.NextDeadline()
+	if !ok {
+		return nil, ErrEmptyTree
+	}
+	if d := next.Sub(queue.clock.Now()); d > 0 {
+		timer := queue.clock.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return queue.DrainDue(queue.clock.Now()), nil
+}
@@ -0,0 +1,142 @@
+package avl
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Test that values are drained in deadline order once due
+func TestDueQueue(t *testing.T) {
+	queue := NewDueQueue[string]()
+	_, ok := queue.NextDeadline()
+	assert(ok, false, "queue.NextDeadline() on empty queue", t)
+
+	start := time.Unix(1000, 0)
+	queue.Schedule(start.Add(3*time.Second), "c")
+	queue.Schedule(start.Add(time.Second), "a")
+	queue.Schedule(start.Add(2*time.Second), "b1")
+	queue.Schedule(start.Add(2*time.Second), "b2")
+	assert(queue.Len(), 4, "queue.Len()", t)
+
+	next, ok := queue.NextDeadline()
+	assert(ok, true, "queue.NextDeadline()", t)
+	assert(next.Equal(start.Add(time.Second)), true, "queue.NextDeadline()", t)
+
+	assertSlice(queue.DrainDue(start), []string{}, "queue.DrainDue(start)", t)
+	assertSlice(queue.DrainDue(start.Add(2*time.Second)), []string{"a", "b1", "b2"}, "queue.DrainDue(start+2s)", t)
+	assert(queue.Len(), 1, "queue.Len() after DrainDue", t)
+	assertSlice(queue.DrainDue(start.Add(time.Hour)), []string{"c"}, "queue.DrainDue(start+1h)", t)
+	assert(queue.Len(), 0, "queue.Len() after draining", t)
+}
+
+// fakeClock is a Clock whose time only moves when advanced
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) NewTimer(d time.Duration) Timer {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	timer := &fakeTimer{deadline: clock.now.Add(d), c: make(chan time.Time, 1)}
+	clock.timers = append(clock.timers, timer)
+	return timer
+}
+
+// Move the clock forward, firing the timers that come due
+func (clock *fakeClock) Advance(d time.Duration) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	clock.now = clock.now.Add(d)
+	pending := clock.timers[:0]
+	for _, timer := range clock.timers {
+		if timer.deadline.After(clock.now) {
+			pending = append(pending, timer)
+		} else {
+			timer.c <- clock.now
+		}
+	}
+	clock.timers = pending
+}
+
+// Returns the number of timers that have not fired
+func (clock *fakeClock) pending() int {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return len(clock.timers)
+}
+
+func (timer *fakeTimer) C() <-chan time.Time {
+	return timer.c
+}
+
+func (timer *fakeTimer) Stop() bool {
+	return false
+}
+
+// Test deadlines far outside the range of Unix nanoseconds, which are kept
+// in order rather than wrapping around
+func TestDueQueueDistantDeadlines(t *testing.T) {
+	queue := NewDueQueue[string]()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	queue.Schedule(time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC), "far future")
+	queue.Schedule(time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC), "far past")
+	queue.Schedule(time.Time{}, "zero time")
+	queue.Schedule(now.Add(time.Hour), "soon")
+
+	next, _ := queue.NextDeadline()
+	assert(next.IsZero(), true, "queue.NextDeadline() should be the zero time", t)
+	assertSlice(queue.DrainDue(now), []string{"zero time", "far past"}, "queue.DrainDue(now)", t)
+	assertSlice(queue.DrainDue(now.AddDate(100, 0, 0)), []string{"soon"}, "queue.DrainDue(now+100y)", t)
+	assert(queue.Len(), 1, "queue.Len() with the far future value left", t)
+}
+
+// Test that Wait blocks on the injected clock until the earliest deadline
+func TestDueQueueWait(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	queue := NewDueQueue[string]()
+	queue.SetClock(clock)
+	_, err := queue.Wait(context.Background())
+	assert(errors.Is(err, ErrEmptyTree), true, "queue.Wait() on empty queue", t)
+
+	queue.ScheduleAfter(time.Minute, "b")
+	queue.ScheduleAfter(time.Second, "a")
+	done := make(chan []string)
+	go func() {
+		due, _ := queue.Wait(context.Background())
+		done <- due
+	}()
+	for clock.pending() == 0 {
+		runtime.Gosched()
+	}
+	clock.Advance(time.Second)
+	assertSlice(<-done, []string{"a"}, "queue.Wait() after 1s", t)
+
+	// Nothing to wait for once the deadline has passed
+	clock.Advance(time.Hour)
+	due, _ := queue.Wait(context.Background())
+	assertSlice(due, []string{"b"}, "queue.Wait() past the deadline", t)
+
+	queue.ScheduleAfter(time.Minute, "c")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = queue.Wait(ctx)
+	assert(err, context.Canceled, "queue.Wait() with a canceled context", t)
+	assert(queue.Len(), 1, "queue.Len() after a canceled Wait", t)
+}
@@ -0,0 +1,33 @@
+package avl
+
+import "errors"
+
+// Errors returned by the package, for callers to branch on with errors.Is.
+// Returned errors may wrap them with details about the failure.
+var (
+	// The tree holds too few values for the operation, usually none. Also
+	// returned by the queues and sets built on trees.
+	ErrEmptyTree = errors.New("tree is empty")
+	// The value or handle is not in the tree
+	ErrNotFound = errors.New("not found")
+	// The bounds of a range are reversed
+	ErrInvalidRange = errors.New("invalid range")
+	// The operation would take the tree past one of its limits
+	ErrCapacityExceeded = errors.New("capacity exceeded")
+	// The tree was modified while it was being iterated
+	ErrConcurrentModification = errors.New("tree modified during iteration")
+	// A node handle is nil, belongs to another tree, or doesn't suit the
+	// operation
+	ErrInvalidNode = errors.New("invalid node")
+	// An argument other than a node or range can never be valid for the
+	// operation
+	ErrInvalidArgument = errors.New("invalid argument")
+	// A value doesn't fit in the type it is converted to or the place it is
+	// put, or an index lies outside the tree
+	ErrValueOutOfRange = errors.New("value out of range")
+	// The change log doesn't hold the history for a version
+	ErrVersionNotRetained = errors.New("version not retained")
+	// The tree's structure is inconsistent, as after a data race or with a
+	// comparator that is not a strict weak ordering
+	ErrCorruptTree = errors.New("tree is corrupt")
+)
@@ -0,0 +1,41 @@
+package avl
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// Test that errors can be told apart with errors.Is
+func TestErrors(t *testing.T) {
+	empty := NewAvlTree[int]()
+	_, err := empty.PopMin()
+	assert(errors.Is(err, ErrEmptyTree), true, "empty.PopMin() is ErrEmptyTree", t)
+	_, _, err = NewPriorityQueue[int, string]().PeekMin()
+	assert(errors.Is(err, ErrEmptyTree), true, "PeekMin() on empty queue is ErrEmptyTree", t)
+	_, _, err = MinGap(populateTree(t, []int{1}))
+	assert(errors.Is(err, ErrEmptyTree), true, "MinGap() of one value is ErrEmptyTree", t)
+
+	tree := populateTree(t, []int{1, 2, 3})
+	_, err = tree.DetachSubtree(nil)
+	assert(errors.Is(err, ErrInvalidNode), true, "DetachSubtree(nil) is ErrInvalidNode", t)
+	err = tree.Graft(tree)
+	assert(errors.Is(err, ErrInvalidArgument), true, "Graft(tree) is ErrInvalidArgument", t)
+	_, err = tree.AsOf(0)
+	assert(errors.Is(err, ErrVersionNotRetained), true, "AsOf() without change log is ErrVersionNotRetained", t)
+
+	pq := NewPriorityQueue[float64, string]()
+	handle, _ := pq.Push(1, "a")
+	pq.PopMin()
+	err = pq.UpdatePriority(handle, 2)
+	assert(errors.Is(err, ErrNotFound), true, "UpdatePriority() of a popped value is ErrNotFound", t)
+	_, err = pq.Push(math.NaN(), "b")
+	assert(errors.Is(err, ErrInvalidArgument), true, "Push() with a NaN priority is ErrInvalidArgument", t)
+
+	err = ExportBitmap(populateTree(t, []int{-1}), make([]uint32, 1), func([]uint32) error { return nil })
+	assert(errors.Is(err, ErrValueOutOfRange), true, "ExportBitmap() of a negative value is ErrValueOutOfRange", t)
+
+	iter := tree.NewIterator()
+	tree.Add(4)
+	assert(errors.Is(iter.Err(), ErrConcurrentModification), true, "iter.Err() after modification is ErrConcurrentModification", t)
+}
@@ -0,0 +1,83 @@
+package avl
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Call fn with every permutation of values, generated with Heap's algorithm.
+// The slice passed to fn is reused between calls.
+func permutations(values []int, fn func([]int)) {
+	perm := slices.Clone(values)
+	var generate func(k int)
+	generate = func(k int) {
+		if k <= 1 {
+			fn(perm)
+			return
+		}
+		for i := 0; i < k-1; i++ {
+			generate(k - 1)
+			if k%2 == 0 {
+				perm[i], perm[k-1] = perm[k-1], perm[i]
+			} else {
+				perm[0], perm[k-1] = perm[k-1], perm[0]
+			}
+		}
+		generate(k - 1)
+	}
+	generate(len(perm))
+}
+
+// Check the tree against a sorted reference slice: contents, size, extremes
+// and the AVL invariants of every node
+func assertMatchesModel(tree *AvlTree[int], model []int, msg string, t *testing.T) {
+	t.Helper()
+	assertSlice(tree.InOrderTraverse(), model, msg, t)
+	assert(tree.Size(), len(model), msg+" size", t)
+	assertBalanced(tree, msg, t)
+	if len(model) > 0 {
+		minVal, _ := tree.GetMin()
+		maxVal, _ := tree.GetMax()
+		assert(minVal, model[0], msg+" min", t)
+		assert(maxVal, model[len(model)-1], msg+" max", t)
+	}
+}
+
+// Insert every permutation of 1..n for each n up to maxN, then remove the
+// values of each tree in every rotation of the insertion order, checking the
+// tree against a reference model after every operation. Duplicates are
+// covered by inserting each permutation's first value a second time.
+func TestExhaustiveShapes(t *testing.T) {
+	maxN := 7
+	if testing.Short() {
+		maxN = 5
+	}
+	for n := 1; n <= maxN; n++ {
+		permutations(rangeWithSteps(1, n, 1), func(perm []int) {
+			values := append(slices.Clone(perm), perm[0])
+			for shift := range values {
+				tree := NewAvlTree[int]()
+				model := make([]int, 0, len(values))
+				for _, v := range values {
+					tree.Add(v)
+					i, _ := slices.BinarySearch(model, v+1)
+					model = slices.Insert(model, i, v)
+				}
+				msg := fmt.Sprintf("insert %v", values)
+				assertMatchesModel(tree, model, msg, t)
+
+				removals := append(slices.Clone(values[shift:]), values[:shift]...)
+				for _, v := range removals {
+					assert(tree.Remove(v), true, fmt.Sprintf("%s, remove %d", msg, v), t)
+					i, _ := slices.BinarySearch(model, v)
+					model = slices.Delete(model, i, i+1)
+					assertMatchesModel(tree, model, fmt.Sprintf("%s, remove %d", msg, v), t)
+				}
+				if t.Failed() {
+					t.FailNow()
+				}
+			}
+		})
+	}
+}
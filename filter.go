@@ -0,0 +1,144 @@
+package avl
+
+import (
+	"hash/maphash"
+	"reflect"
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+// TreeOption configures a tree created by NewAvlTree or NewAvlTreeFunc.
+type TreeOption func(*treeConfig)
+
+type treeConfig struct {
+	negativeFilter bool
+	// A func(T) T, see WithCloneFunc
+	clone     any
+	nilPolicy NilPolicy
+}
+
+// Keep a Bloom filter of the tree's values alongside it, so that Contains
+// answers most lookups of absent values without descending the tree, e.g. for
+// workloads dominated by negative lookups. The filter takes about 10 bits per
+// value and lets about 1% of absent values through to the descent. Removals
+// can't be taken out of a Bloom filter, so the filter is rebuilt from the
+// tree once removals reach half the tree's size, and as the tree grows past
+// the size the filter was built for; both are amortized O(1) per mutation.
+func WithNegativeLookupFilter() TreeOption {
+	return func(config *treeConfig) {
+		config.negativeFilter = true
+	}
+}
+
+const (
+	filterBitsPerValue = 10
+	filterHashes       = 7
+	filterMinCapacity  = 1024
+)
+
+// negativeFilter is a Bloom filter over a tree's values
+type negativeFilter[T any] struct {
+	bits []uint64
+	// Number of values the filter was sized for
+	capacity int
+	// Values added to the filter, and removed from the tree, since the
+	// filter was last emptied
+	added, removed int
+	hash           func(T) uint64
+}
+
+// Returns an empty filter hashing values by their memory representation, or
+// by their contents for string types
+func newNegativeFilter[T constraints.Ordered]() *negativeFilter[T] {
+	var zero T
+	seed := maphash.MakeSeed()
+	filter := &negativeFilter[T]{hash: func(value T) uint64 {
+		// Equal values must hash alike, and -0.0 == 0.0
+		if value == zero {
+			value = zero
+		}
+		return maphash.Bytes(seed, unsafe.Slice((*byte)(unsafe.Pointer(&value)), unsafe.Sizeof(value)))
+	}}
+	if reflect.TypeOf(zero).Kind() == reflect.String {
+		filter.hash = func(value T) uint64 {
+			return maphash.String(seed, *(*string)(unsafe.Pointer(&value)))
+		}
+	}
+	filter.reset(0)
+	return filter
+}
+
+// Empty the filter, sizing it for the given number of values
+func (filter *negativeFilter[T]) reset(size int) {
+	filter.capacity = max(2*size, filterMinCapacity)
+	filter.bits = make([]uint64, (filter.capacity*filterBitsPerValue+63)/64)
+	filter.added, filter.removed = 0, 0
+}
+
+// Call visit with each of the value's bit positions, derived from one hash by
+// double hashing, until it returns false. Returns false if visit did.
+func (filter *negativeFilter[T]) probe(value T, visit func(uint64) bool) bool {
+	h := filter.hash(value)
+	h1, h2 := h&0xffffffff, h>>32|1
+	n := uint64(len(filter.bits) * 64)
+	for i := uint64(0); i < filterHashes; i++ {
+		if !visit((h1 + i*h2) % n) {
+			return false
+		}
+	}
+	return true
+}
+
+func (filter *negativeFilter[T]) add(value T) {
+	filter.probe(value, func(bit uint64) bool {
+		filter.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+	filter.added += 1
+}
+
+// Returns false if the value is certainly not in the tree
+func (filter *negativeFilter[T]) mayContain(value T) bool {
+	return filter.probe(value, func(bit uint64) bool {
+		return filter.bits[bit/64]&(1<<(bit%64)) != 0
+	})
+}
+
+// Returns true once the filter holds too many values for its size, or too
+// many removed values, to stay selective
+func (filter *negativeFilter[T]) needsRebuild(size int) bool {
+	return filter.added > filter.capacity || filter.removed > max(size/2, filterMinCapacity/2)
+}
+
+// Update the tree's filter, if any, for a recorded change
+func (tree *AvlTree[T]) updateFilter(op ChangeOp, value T) {
+	filter := tree.filter
+	if filter == nil {
+		return
+	}
+	switch op {
+	case ChangeAdd:
+		filter.add(value)
+	case ChangeRemove:
+		filter.removed += 1
+	case ChangeUpdate:
+		// The replaced value can't be taken out, so it counts as removed
+		filter.add(value)
+		filter.removed += 1
+	case ChangeClear:
+		filter.reset(0)
+		return
+	}
+	if filter.needsRebuild(tree.size) {
+		tree.rebuildFilter()
+	}
+}
+
+// Rebuild the tree's filter from its current values
+func (tree *AvlTree[T]) rebuildFilter() {
+	tree.filter.reset(tree.size)
+	for node := tree.minNode(); node != nil; node = nextNode(node) {
+		tree.filter.add(node.value)
+	}
+}
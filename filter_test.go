@@ -0,0 +1,96 @@
+package avl
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Test that a filtered tree answers lookups like an unfiltered one through
+// every kind of mutation, including the rebuilds they trigger
+func TestNegativeLookupFilter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	filtered, plain := NewAvlTree[int](WithNegativeLookupFilter()), NewAvlTree[int]()
+	check := func(msg string) {
+		t.Helper()
+		for v := -10; v < 5000; v++ {
+			if filtered.Contains(v) != plain.Contains(v) {
+				t.Fatalf("%s: Contains(%d) is %v, expected %v", msg, v, filtered.Contains(v), plain.Contains(v))
+			}
+		}
+	}
+	for i := 0; i < 3000; i++ {
+		v := r.Intn(5000)
+		filtered.Add(v)
+		plain.Add(v)
+	}
+	check("after Add")
+	for i := 0; i < 2000; i++ {
+		v := r.Intn(5000)
+		assert(filtered.Remove(v), plain.Remove(v), fmt.Sprintf("Remove(%d)", v), t)
+	}
+	check("after Remove")
+
+	filtered.RemoveRange(1000, 2000, nil)
+	plain.RemoveRange(1000, 2000, nil)
+	filtered.DrainMin(100)
+	plain.DrainMin(100)
+	check("after RemoveRange and DrainMin")
+	filtered.Graft(populateTree(t, []int{-5, 6000}))
+	plain.Graft(populateTree(t, []int{-5, 6000}))
+	check("after Graft")
+	filtered.Clear()
+	plain.Clear()
+	check("after Clear")
+	filtered.Add(7)
+	assert(filtered.Contains(7), true, "Contains(7) after Clear and Add", t)
+}
+
+// Test that most absent values are rejected by the filter itself
+func TestNegativeLookupFilterSelectivity(t *testing.T) {
+	tree := NewAvlTree[int](WithNegativeLookupFilter())
+	for v := 0; v < 100_000; v += 2 {
+		tree.Add(v)
+	}
+	passed := 0
+	for v := 1; v < 100_000; v += 2 {
+		if tree.filter.mayContain(v) {
+			passed += 1
+		}
+	}
+	if rate := float64(passed) / 50_000; rate > 0.03 {
+		t.Errorf("filter false positive rate %.3f", rate)
+	}
+}
+
+// Test that equal floats and strings are found through the filter
+func TestNegativeLookupFilterTypes(t *testing.T) {
+	floats := NewAvlTree[float64](WithNegativeLookupFilter())
+	floats.Add(math.Copysign(0, -1))
+	assert(floats.Contains(0), true, "floats.Contains(0) after adding -0", t)
+
+	strings := NewAvlTree[string](WithNegativeLookupFilter())
+	strings.Add(fmt.Sprint("za'", "atar"))
+	assert(strings.Contains("za'atar"), true, "strings.Contains(\"za'atar\")", t)
+	assert(strings.Contains("tahini"), false, "strings.Contains(\"tahini\")", t)
+}
+
+func benchmarkNegativeLookups(b *testing.B, opts ...TreeOption) {
+	tree := NewAvlTree[int](opts...)
+	for v := 0; v < 1<<20; v += 2 {
+		tree.Add(v)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Contains(2*(i%(1<<19)) + 1)
+	}
+}
+
+func BenchmarkNegativeLookups(b *testing.B) {
+	benchmarkNegativeLookups(b)
+}
+
+func BenchmarkNegativeLookupsFiltered(b *testing.B) {
+	benchmarkNegativeLookups(b, WithNegativeLookupFilter())
+}
@@ -0,0 +1,175 @@
+package avl
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+	"sort"
+
+	"golang.org/x/exp/constraints"
+)
+
+// FrozenTree is an immutable snapshot of a tree's values, laid out for fast
+// lookups and range scans rather than for updates. Values are stored in a
+// single slice in breadth-first (Eytzinger) order of a complete binary search
+// tree: the children of position k are at 2k and 2k+1, so the first levels of
+// every search share a few cache lines, and there are no pointers to chase.
+// A FrozenTree from AdoptSortedSlice instead searches the caller's sorted
+// slice where it lies.
+type FrozenTree[T any] struct {
+	// Values in breadth-first order, starting at index 1, or the adopted
+	// slice in ascending order
+	values  []T
+	adopted bool
+	// The ordering of the tree the snapshot was taken from
+	less func(a, b T) bool
+}
+
+// Returns a frozen snapshot of the tree's values. Later changes to the tree
+// don't affect the snapshot.
+func (tree *AvlTree[T]) Freeze() *FrozenTree[T] {
+	sorted := tree.InOrderTraverse()
+	frozen := &FrozenTree[T]{values: make([]T, len(sorted)+1), less: tree.less}
+	next := 0
+	var fill func(k int)
+	fill = func(k int) {
+		if k >= len(frozen.values) {
+			return
+		}
+		fill(2 * k)
+		frozen.values[k] = sorted[next]
+		next += 1
+		fill(2*k + 1)
+	}
+	fill(1)
+	return frozen
+}
+
+// Returns a FrozenTree over a slice already in ascending order, without
+// copying it: lookups and range scans binary search the slice in place, so
+// adopting it takes no memory beyond the FrozenTree itself, e.g. for
+// read-mostly indexes over datasets too large to hold twice. The caller keeps
+// ownership of the slice and must not modify it while the FrozenTree is in
+// use. Checking the order takes O(n), but reads the values only once.
+// Returns an error wrapping ErrInvalidArgument if the values are out of order.
+func AdoptSortedSlice[T constraints.Ordered](values []T) (*FrozenTree[T], error) {
+	return AdoptSortedSliceFunc(values, func(a, b T) bool { return a < b })
+}
+
+// Returns a FrozenTree over a slice already in ascending order by the given
+// less function, without copying it. See AdoptSortedSlice.
+func AdoptSortedSliceFunc[T any](values []T, less func(a, b T) bool) (*FrozenTree[T], error) {
+	for i := 1; i < len(values); i++ {
+		if less(values[i], values[i-1]) {
+			return nil, fmt.Errorf("%w: value %v at index %d follows %v", ErrInvalidArgument, values[i], i, values[i-1])
+		}
+	}
+	return &FrozenTree[T]{values: values, adopted: true, less: less}, nil
+}
+
+// Return the number of values in the snapshot
+func (frozen *FrozenTree[T]) Len() int {
+	if frozen.adopted {
+		return len(frozen.values)
+	}
+	return len(frozen.values) - 1
+}
+
+// Returns a bool indicating whether the value exists in the snapshot
+func (frozen *FrozenTree[T]) Contains(value T) bool {
+	k := frozen.lowerBound(value)
+	return k != 0 && !frozen.less(value, frozen.at(k))
+}
+
+// Returns an iterator over the values greater than or equal to from and less
+// than to, in ascending order
+func (frozen *FrozenTree[T]) Range(from, to T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for k := frozen.lowerBound(from); k != 0 && frozen.less(frozen.at(k), to); k = frozen.next(k) {
+			if !yield(frozen.at(k)) {
+				return
+			}
+		}
+	}
+}
+
+// Returns a slice of the snapshot's values in-order
+func (frozen *FrozenTree[T]) InOrderTraverse() []T {
+	values := make([]T, 0, frozen.Len())
+	if frozen.Len() == 0 {
+		return values
+	}
+	for k := frozen.first(); k != 0; k = frozen.next(k) {
+		values = append(values, frozen.at(k))
+	}
+	return values
+}
+
+// %%% Private methods %%%
+
+// Positions count from 1 in both layouts, so that 0 can stand for none. In
+// an adopted slice, position k holds the value at index k-1.
+
+// Returns the value at position k
+func (frozen *FrozenTree[T]) at(k int) T {
+	if frozen.adopted {
+		return frozen.values[k-1]
+	}
+	return frozen.values[k]
+}
+
+// Returns the position of the first value greater than or equal to the given
+// value, or 0 if there is none
+func (frozen *FrozenTree[T]) lowerBound(value T) int {
+	if frozen.adopted {
+		i := sort.Search(len(frozen.values), func(i int) bool {
+			return !frozen.less(frozen.values[i], value)
+		})
+		if i == len(frozen.values) {
+			return 0
+		}
+		return i + 1
+	}
+	k := 1
+	for k < len(frozen.values) {
+		if frozen.less(frozen.values[k], value) {
+			k = 2*k + 1
+		} else {
+			k = 2 * k
+		}
+	}
+	// Undo the right turns taken after the last left turn, and the left turn
+	return k >> (bits.TrailingZeros(^uint(k)) + 1)
+}
+
+// Returns the position of the smallest value
+func (frozen *FrozenTree[T]) first() int {
+	if frozen.adopted {
+		return 1
+	}
+	k := 1
+	for 2*k < len(frozen.values) {
+		k = 2 * k
+	}
+	return k
+}
+
+// Returns the position of the in-order successor of position k, or 0 if k is
+// the last position
+func (frozen *FrozenTree[T]) next(k int) int {
+	if frozen.adopted {
+		if k < len(frozen.values) {
+			return k + 1
+		}
+		return 0
+	}
+	if 2*k+1 < len(frozen.values) {
+		k = 2*k + 1
+		for 2*k < len(frozen.values) {
+			k = 2 * k
+		}
+		return k
+	}
+	// Climb out of the right children, then out of one left child
+	return k >> (bits.TrailingZeros(^uint(k)) + 1)
+}
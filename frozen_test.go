@@ -0,0 +1,104 @@
+package avl
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// Test that a frozen snapshot answers lookups and range scans like the tree
+// it was taken from, for every tree size up to a few levels
+func TestFreeze(t *testing.T) {
+	for n := 0; n <= 40; n++ {
+		tree := NewAvlTree[int]()
+		for v := 0; v < n; v++ {
+			tree.Add(v * 2)
+		}
+		adopted, err := AdoptSortedSlice(tree.InOrderTraverse())
+		assert(err, nil, "AdoptSortedSlice() error", t)
+		for name, frozen := range map[string]*FrozenTree[int]{"frozen": tree.Freeze(), "adopted": adopted} {
+			assert(frozen.Len(), n, name+".Len()", t)
+			assertSlice(frozen.InOrderTraverse(), tree.InOrderTraverse(), name+".InOrderTraverse()", t)
+
+			for v := -1; v <= 2*n; v++ {
+				assert(frozen.Contains(v), tree.Contains(v), fmt.Sprintf("%s.Contains(%d) of %d values", name, v, n), t)
+				expected := make([]int, 0)
+				for _, w := range tree.InOrderTraverse() {
+					if w >= v && w < v+7 {
+						expected = append(expected, w)
+					}
+				}
+				actual := slices.Collect(frozen.Range(v, v+7))
+				if actual == nil {
+					actual = []int{}
+				}
+				assertSlice(actual, expected, fmt.Sprintf("%s.Range(%d, %d) of %d values", name, v, v+7, n), t)
+			}
+		}
+	}
+}
+
+// Test that adopting a slice searches it in place rather than copying it
+func TestAdoptSortedSlice(t *testing.T) {
+	values := []int{1, 3, 3, 5, 8}
+	frozen, _ := AdoptSortedSlice(values)
+	assert(&frozen.values[0] == &values[0], true, "the adopted slice should not be copied", t)
+	assertSlice(slices.Collect(frozen.Range(3, 6)), []int{3, 3, 5}, "frozen.Range(3, 6)", t)
+
+	_, err := AdoptSortedSlice([]int{2, 1})
+	assert(errors.Is(err, ErrInvalidArgument), true, "AdoptSortedSlice() of unsorted values", t)
+	byLength, err := AdoptSortedSliceFunc([]string{"b", "aa", "ccc"}, func(a, b string) bool { return len(a) < len(b) })
+	assert(err, nil, "AdoptSortedSliceFunc() error", t)
+	assert(byLength.Contains("zz"), true, "byLength.Contains(\"zz\")", t)
+}
+
+// Test that the snapshot doesn't follow later changes to the tree
+func TestFreezeIndependent(t *testing.T) {
+	tree := populateTree(t, []int{3, 1, 2})
+	frozen := tree.Freeze()
+	tree.Add(4)
+	tree.Remove(1)
+	assertSlice(frozen.InOrderTraverse(), []int{1, 2, 3}, "frozen.InOrderTraverse() after tree changes", t)
+}
+
+// Sizes for lookup benchmarks. The largest shows the layout's effect once the
+// data no longer fits in cache; skipped with -short.
+var lookupBenchmarkSizes = []int{1 << 10, 1 << 20, 10_000_000}
+
+func benchmarkLookups(b *testing.B, contains func(*AvlTree[int]) func(int) bool) {
+	for _, n := range lookupBenchmarkSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			if n > 1<<20 && testing.Short() {
+				b.Skip("large benchmark")
+			}
+			values := make([]int, n)
+			for i := range values {
+				values[i] = 2 * i
+			}
+			tree := NewAvlTree[int]()
+			tree.root = buildFromSorted(values, nil)
+			tree.size, tree.height = n, subtreeHeight(tree.root)
+			tree.resetEdges()
+			lookup := contains(tree)
+			r := rand.New(rand.NewSource(1))
+			queries := make([]int, 1<<16)
+			for i := range queries {
+				queries[i] = r.Intn(2 * n)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				lookup(queries[i%len(queries)])
+			}
+		})
+	}
+}
+
+func BenchmarkTreeContains(b *testing.B) {
+	benchmarkLookups(b, func(tree *AvlTree[int]) func(int) bool { return tree.Contains })
+}
+
+func BenchmarkFrozenContains(b *testing.B) {
+	benchmarkLookups(b, func(tree *AvlTree[int]) func(int) bool { return tree.Freeze().Contains })
+}
@@ -0,0 +1,108 @@
+package avl
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Number is the set of element types whose values can be subtracted to
+// measure the distance between them.
+type Number interface {
+	constraints.Integer | constraints.Float
+}
+
+// The functions in this file measure distances between adjacent values, so
+// they expect trees ordered by <, as trees from NewAvlTree are.
+
+// Returns the pair of adjacent values in the tree with the smallest
+// difference between them, found by an in-order scan in O(n). Differences
+// are computed in T, so they must not overflow it.
+// Returns an error if the tree holds fewer than two values.
+func MinGap[T Number](tree *AvlTree[T]) (T, T, error) {
+	return findGap(tree, func(gap, best T) bool { return gap < best })
+}
+
+// Returns the pair of adjacent values in the tree with the largest difference
+// between them, i.e. the widest hole between stored values. See MinGap.
+func MaxGap[T Number](tree *AvlTree[T]) (T, T, error) {
+	return findGap(tree, func(gap, best T) bool { return gap > best })
+}
+
+// Returns the first adjacent pair whose gap is better than all before it
+func findGap[T Number](tree *AvlTree[T], better func(gap, best T) bool) (T, T, error) {
+	if tree.size < 2 {
+		var zero T
+		return zero, zero, fmt.Errorf("tree has fewer than two values: %w", ErrEmptyTree)
+	}
+	prev := tree.minNode()
+	lo, hi := prev, nextNode(prev)
+	for node := hi; node != nil; node = nextNode(node) {
+		if better(node.value-prev.value, hi.value-lo.value) {
+			lo, hi = prev, node
+		}
+		prev = node
+	}
+	return lo.value, hi.value, nil
+}
+
+// Returns the smallest value greater than or equal to from that is not in the
+// tree, e.g. the next free id of an allocator whose allocated ids are kept in
+// the tree. In a run of distinct consecutive values starting at from, each
+// value is as far from from as its rank is from the rank of from, so in a
+// tree of distinct values the end of the run is found by a single descent
+// comparing values with ranks, in O(log n) however long the run. Extra copies
+// of a value throw the ranks off, so once a tree may hold equal values the run
+// is walked in order instead, in O(log n + k) for a run of k nodes. Returns
+// false if every value from from up to the maximum of T is in the tree.
+func SmallestMissing[T constraints.Integer](tree *AvlTree[T], from T) (T, bool) {
+	start := tree.CountLess(from)
+	first := tree.nodeAt(start)
+	if first == nil || first.value != from {
+		return from, true
+	}
+	if tree.mayHoldDuplicates {
+		return walkRun(first)
+	}
+
+	// Find the last node not past the end of the run: the values before from
+	// come before it, and the values from from on are no further from from
+	// than their rank is from start. Differences are taken in uint64, where
+	// they can't overflow for values at or above from.
+	var last *Node[T]
+	index := 0
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return SmallestMissing(tree, from)
+			}
+			return from, false // the tree is corrupt, see Err
+		}
+		rank := index + nodeCount(curr.children[dirLeft])
+		if rank < start || uint64(curr.value)-uint64(from) <= uint64(rank-start) {
+			last = curr
+			index = rank + 1
+			curr = curr.children[dirRight]
+		} else {
+			curr = curr.children[dirLeft]
+		}
+	}
+	if last.value+1 < last.value {
+		return last.value, false // the run reaches the maximum of T
+	}
+	return last.value + 1, true
+}
+
+// Returns the value after the run of consecutive values starting at the
+// node, skipping extra copies, or false if the run reaches the maximum of T
+func walkRun[T constraints.Integer](node *Node[T]) (T, bool) {
+	last := node.value
+	for node = nextNode(node); node != nil && uint64(node.value)-uint64(last) <= 1; node = nextNode(node) {
+		last = node.value
+	}
+	if last+1 < last {
+		return last, false
+	}
+	return last + 1, true
+}
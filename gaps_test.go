@@ -0,0 +1,145 @@
+package avl
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Test finding the closest and farthest adjacent values
+func TestMinMaxGap(t *testing.T) {
+	tree := populateTree(t, []int{10, 1, 4, 20, 5, 50})
+	lo, hi, err := MinGap(tree)
+	assert(err, nil, "MinGap(tree)", t)
+	assert([2]int{lo, hi}, [2]int{4, 5}, "MinGap(tree)", t)
+
+	lo, hi, err = MaxGap(tree)
+	assert(err, nil, "MaxGap(tree)", t)
+	assert([2]int{lo, hi}, [2]int{20, 50}, "MaxGap(tree)", t)
+
+	tree.Add(10)
+	lo, hi, _ = MinGap(tree)
+	assert([2]int{lo, hi}, [2]int{10, 10}, "MinGap(tree) with duplicate", t)
+
+	floats := NewAvlTree[float64]()
+	floats.Add(0.5)
+	_, _, err = MinGap(floats)
+	assert(err != nil, true, "MinGap(single value)", t)
+	floats.Add(2.5)
+	floats.Add(1.0)
+	lo64, hi64, _ := MaxGap(floats)
+	assert([2]float64{lo64, hi64}, [2]float64{1.0, 2.5}, "MaxGap(floats)", t)
+}
+
+// Test finding the first free id at or above a starting point
+func TestSmallestMissing(t *testing.T) {
+	tree := populateTree(t, []int{1, 2, 3, 5, 6, 6, 7, 10})
+	expected := map[int]int{-5: -5, 0: 0, 1: 4, 3: 4, 4: 4, 5: 8, 6: 8, 9: 9, 10: 11, 20: 20}
+	for from, missing := range expected {
+		actual, ok := SmallestMissing(tree, from)
+		assert(ok, true, "SmallestMissing() ok", t)
+		assert(actual, missing, "SmallestMissing()", t)
+	}
+
+	full := NewAvlTree[uint8]()
+	for v := 250; v <= 255; v++ {
+		full.Add(uint8(v))
+	}
+	_, ok := SmallestMissing(full, 250)
+	assert(ok, false, "SmallestMissing() with no free value", t)
+	actual, ok := SmallestMissing(full, 0)
+	assert(ok && actual == 0, true, "SmallestMissing(full, 0)", t)
+
+	// Runs spanning the whole range of a signed type
+	signed := NewAvlTree[int8]()
+	for v := -128; v <= 126; v++ {
+		signed.Add(int8(v))
+	}
+	missing, ok := SmallestMissing(signed, -128)
+	assert(ok && missing == 127, true, "SmallestMissing(signed, -128)", t)
+	signed.Add(127)
+	_, ok = SmallestMissing(signed, -128)
+	assert(ok, false, "SmallestMissing() of a full signed range", t)
+
+	// Against a walk of sets with long runs
+	r := rand.New(rand.NewSource(1))
+	ids := NewAvlTree[int]()
+	for v := range 2000 {
+		if r.Intn(50) != 0 {
+			ids.Add(v)
+		}
+	}
+	for from := -1; from <= 2001; from++ {
+		expected := from
+		for ids.Contains(expected) {
+			expected += 1
+		}
+		actual, _ := SmallestMissing(ids, from)
+		assert(actual, expected, fmt.Sprintf("SmallestMissing(ids, %d)", from), t)
+	}
+	assert(ids.mayHoldDuplicates, false, "a tree of distinct ids takes the descent", t)
+}
+
+// Test that extra copies of a value don't hide a gap before them
+func TestSmallestMissingDuplicates(t *testing.T) {
+	tree := populateTree(t, []int{0, 0, 2})
+	missing, ok := SmallestMissing(tree, 0)
+	assert(ok && missing == 1, true, "SmallestMissing({0, 0, 2}, 0)", t)
+	tree.Remove(0)
+	missing, _ = SmallestMissing(tree, 0)
+	assert(missing, 1, "SmallestMissing({0, 2}, 0) after removing the copy", t)
+
+	// Copies made by an in-place update or a graft
+	updated := populateTree(t, []int{1, 2, 3})
+	updated.UpdateValue(updated.FindNode(2), 1)
+	missing, _ = SmallestMissing(updated, 1)
+	assert(missing, 2, "SmallestMissing() after UpdateValue makes a copy", t)
+	grafted := populateTree(t, []int{5, 6})
+	grafted.Graft(populateTree(t, []int{6, 8}))
+	missing, _ = SmallestMissing(grafted, 5)
+	assert(missing, 7, "SmallestMissing() after Graft joins copies", t)
+
+	signed := NewAvlTree[int8]()
+	for v := -128; v <= 127; v++ {
+		signed.Add(int8(v))
+		signed.Add(int8(v))
+	}
+	_, ok = SmallestMissing(signed, -128)
+	assert(ok, false, "SmallestMissing() of a full signed range with copies", t)
+	signed.RemoveRange(0, 1, nil)
+	gap, _ := SmallestMissing(signed, -128)
+	assert(gap, 0, "SmallestMissing() of a signed range with copies and a gap", t)
+
+	// Against a walk of multisets, built by each kind of insertion
+	r := rand.New(rand.NewSource(2))
+	for round := 0; round < 20; round++ {
+		values := make([]int, 0)
+		for v := range 300 {
+			for copies := r.Intn(3); copies > 0; copies-- {
+				values = append(values, v)
+			}
+		}
+		sorted, _ := NewFromSortedSlice(values)
+		built := map[string]*AvlTree[int]{
+			"Add":                NewAvlTree[int](),
+			"AppendMax":          NewAvlTree[int](),
+			"NewFromSortedSlice": sorted,
+		}
+		for _, v := range r.Perm(len(values)) {
+			built["Add"].Add(values[v])
+		}
+		for _, v := range values {
+			built["AppendMax"].AppendMax(v)
+		}
+		for name, tree := range built {
+			for from := -1; from <= 301; from += 1 + r.Intn(10) {
+				expected := from
+				for tree.Contains(expected) {
+					expected += 1
+				}
+				actual, _ := SmallestMissing(tree, from)
+				assert(actual, expected, fmt.Sprintf("%s: SmallestMissing(%d)", name, from), t)
+			}
+		}
+	}
+}
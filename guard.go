@@ -0,0 +1,132 @@
+package avl
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// HealReport describes a tree rebuilt by Heal.
+type HealReport struct {
+	// The corruption that led to the rebuild, if it was found by the height
+	// guard
+	Cause error
+	// Values reachable from the root, which the rebuilt tree holds
+	Recovered int
+	// Values the tree counted that could not be reached, and were lost
+	Lost int
+}
+
+// Check the depth of every descent from the root, by insertions, lookups
+// and searches such as Floor, FirstWhere or PartitionPoint, against the
+// greatest height an AVL tree of the tree's size can have, about
+// 1.44·log2(n). A descent going deeper can only mean the tree is corrupt, e.g.
+// from a data race or a comparator that is not a strict weak ordering, and
+// would otherwise corrupt it further or loop forever on a cycle. The descent
+// is abandoned, leaving a lookup unsuccessful and an insertion undone, and the
+// corruption is kept for Err and reported according to the misuse policy:
+// TryAdd returns it, and with PanicOnMisuse the call panics with an error
+// wrapping ErrCorruptTree. SetSelfHealing rebuilds the tree instead.
+// The guard costs a counter per level of each descent, so it is off by
+// default.
+func (tree *AvlTree[T]) SetHeightGuard(enabled bool) {
+	tree.heightGuard = enabled
+}
+
+// Returns the first corruption found by the height guard, wrapping
+// ErrCorruptTree, or nil if none was found. DumpState shows where the
+// structure is broken.
+func (tree *AvlTree[T]) Err() error {
+	return tree.corruption
+}
+
+// Rebuild a corrupt tree from the values that can still be reached from its
+// root, as a last resort for long-running services that would rather lose
+// some values than fail outright. Every node reachable through child links is
+// visited once, even if the links form a cycle, and its value kept; the values
+// are then sorted, keeping equal ones in the order found, and built into a
+// fresh balanced tree. This clears Err. If every value was recovered, the
+// rebuild only changes the tree's shape and is not recorded; otherwise it is
+// recorded as a ChangeClear followed by a ChangeAdd of each recovered value,
+// so followers applying the change log end up with the same values. The
+// negative lookup filter, if any, is rebuilt.
+// Returns a report of the values recovered and lost.
+func (tree *AvlTree[T]) Heal() HealReport {
+	values := make([]T, 0, tree.size)
+	visited := make(map[*Node[T]]bool)
+	stack := make([]*Node[T], 0)
+	for curr := tree.root; curr != nil || len(stack) > 0; {
+		for curr != nil && !visited[curr] {
+			visited[curr] = true
+			stack = append(stack, curr)
+			curr = curr.children[dirLeft]
+		}
+		curr = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		values = append(values, curr.value)
+		curr = curr.children[dirRight]
+		if visited[curr] {
+			curr = nil
+		}
+	}
+	slices.SortStableFunc(values, tree.compare)
+
+	report := HealReport{Cause: tree.corruption, Recovered: len(values), Lost: max(0, tree.size-len(values))}
+	changed := len(values) != tree.size
+	tree.replaceValues(values)
+	if changed {
+		var zero T
+		tree.recordChange(ChangeClear, zero)
+		for _, v := range values {
+			tree.recordChange(ChangeAdd, v)
+		}
+	} else {
+		tree.reshapes += 1
+	}
+	if tree.filter != nil {
+		tree.rebuildFilter()
+	}
+	tree.corruption = nil
+	return report
+}
+
+// Heal the tree automatically when the height guard finds it corrupt, rather
+// than reporting the corruption, and pass the report to onHeal. The
+// interrupted call then carries on against the rebuilt tree. Enables the
+// height guard; a nil onHeal turns self-healing off again but leaves the
+// guard on.
+func (tree *AvlTree[T]) SetSelfHealing(onHeal func(HealReport)) {
+	tree.heightGuard = true
+	tree.onHeal = onHeal
+}
+
+// %%% Tree private methods %%%
+
+// Returns the greatest depth a descent may reach, or math.MaxInt without the
+// height guard. An AVL tree of n nodes is less than 1.4405·log2(n+2) high,
+// which 1.5·bits.Len(n+1) bounds from above; the slack of 2 levels covers a
+// descent that is one node longer than the tree is high.
+func (tree *AvlTree[T]) depthLimit() int {
+	if !tree.heightGuard {
+		return math.MaxInt
+	}
+	return 3*bits.Len(uint(tree.size+1))/2 + 2
+}
+
+// Record and report a descent that passed the depth limit, or heal the tree
+// if self-healing is on.
+// Returns nil if the tree was healed and the descent can be retried, and the
+// corruption otherwise.
+func (tree *AvlTree[T]) detectedCorruption(depth, limit int) error {
+	err := fmt.Errorf("%w: descent passed depth %d, above the limit of %d for %d values and height %d",
+		ErrCorruptTree, depth, limit, tree.size, tree.height)
+	if tree.corruption == nil {
+		tree.corruption = err
+	}
+	if tree.onHeal != nil {
+		tree.onHeal(tree.Heal())
+		return nil
+	}
+	return tree.misuse(err)
+}
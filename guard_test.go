@@ -0,0 +1,120 @@
+package avl
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// Test that the height guard never trips on a tree that is only modified
+// through its methods
+func TestHeightGuardNoFalsePositives(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := NewAvlTree[int]()
+	tree.SetHeightGuard(true)
+	for i := 0; i < 5000; i++ {
+		v := r.Intn(500)
+		if r.Intn(3) == 0 {
+			tree.Remove(v)
+		} else {
+			tree.Add(v)
+		}
+		tree.Contains(v)
+	}
+	for v := range 2000 {
+		tree.Add(v)
+	}
+	assert(tree.Err(), nil, "tree.Err() after random operations", t)
+}
+
+// Test that a descent into a cycle is stopped and reported
+func TestHeightGuardDetectsCycle(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(1, 15, 1))
+	tree.SetHeightGuard(true)
+	// Link the minimum back to the root, as a race between rotations might
+	tree.minNode().children[dirLeft] = tree.root
+
+	assert(tree.Contains(0), false, "tree.Contains() of a value below a cycle", t)
+	assert(errors.Is(tree.Err(), ErrCorruptTree), true, "tree.Err() after a cycle", t)
+	size, leftCount := tree.Len(), tree.root.children[dirLeft].count
+	err := tree.TryAdd(0)
+	assert(errors.Is(err, ErrCorruptTree), true, "tree.TryAdd() below a cycle", t)
+	assert(tree.Len(), size, "tree.Len() after an abandoned Add", t)
+	assert(tree.root.count, size, "root subtree size after an abandoned Add", t)
+	assert(tree.root.children[dirLeft].count, leftCount, "left subtree size after an abandoned Add", t)
+	tree.Add(0)
+	assert(tree.root.count, size, "root subtree size after an abandoned Add", t)
+
+	// Every other descent is guarded as well
+	_, ok := tree.Floor(0)
+	assert(ok, false, "tree.Floor() below a cycle", t)
+	_, ok = tree.Successor(0)
+	assert(ok, false, "tree.Successor() below a cycle", t)
+	_, ok = tree.Predecessor(1)
+	assert(ok, false, "tree.Predecessor() below a cycle", t)
+	_, ok = tree.FirstWhere(func(v int) bool { return v >= 0 })
+	assert(ok, false, "tree.FirstWhere() below a cycle", t)
+	_, ok = tree.LastWhere(func(v int) bool { return v < 0 })
+	assert(ok, false, "tree.LastWhere() below a cycle", t)
+	_, _, okFloor, okCeil := tree.FloorCeilingPair(0)
+	assert(okFloor || okCeil, false, "tree.FloorCeilingPair() below a cycle", t)
+	_, rank, ok := tree.PartitionPoint(func(v int) bool { return v < 0 })
+	assert(ok, false, "tree.PartitionPoint() below a cycle", t)
+	assert(rank, size, "tree.PartitionPoint() rank below a cycle", t)
+	_, err = tree.At(0)
+	assert(errors.Is(err, ErrValueOutOfRange), true, "tree.At() below a cycle", t)
+
+	tree.SetMisusePolicy(PanicOnMisuse)
+	defer func() {
+		err, _ := recover().(error)
+		assert(errors.Is(err, ErrCorruptTree), true, "panic from a descent into a cycle", t)
+	}()
+	tree.Contains(0)
+	t.Errorf("tree.Contains() into a cycle did not panic")
+}
+
+// Test that Heal recovers the reachable values of a tree with a cycle and
+// counts the ones cut off
+func TestHeal(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(1, 15, 1))
+	since := tree.EnableChangeLog()
+	follower := populateTree(t, rangeWithSteps(1, 15, 1))
+	// Cut off the root's right subtree by linking it back to the root
+	lost := nodeCount(tree.root.children[dirRight])
+	tree.root.children[dirRight] = tree.root
+
+	report := tree.Heal()
+	assert(report.Recovered, 15-lost, "report.Recovered", t)
+	assert(report.Lost, lost, "report.Lost", t)
+	assertSlice(tree.InOrderTraverse(), rangeWithSteps(1, 15-lost, 1), "tree after Heal", t)
+	assertBalanced(tree, "tree after Heal", t)
+
+	// Losing values is recorded, so followers drop them too
+	follower.ApplyChanges(changesSince(t, tree, since))
+	assertSlice(follower.InOrderTraverse(), tree.InOrderTraverse(), "follower after Heal", t)
+
+	// Healing a sound tree keeps every value, and so leaves the version alone
+	version := tree.Version()
+	report = tree.Heal()
+	assert(report, HealReport{Recovered: 15 - lost}, "report of a sound tree", t)
+	assert(tree.Version(), version, "tree.Version() after healing a sound tree", t)
+}
+
+// Test that self-healing rebuilds the tree when the guard trips and carries
+// on with the interrupted call
+func TestSelfHealing(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(1, 15, 1))
+	reports := make([]HealReport, 0)
+	tree.SetSelfHealing(func(report HealReport) {
+		reports = append(reports, report)
+	})
+	tree.minNode().children[dirLeft] = tree.root
+
+	tree.Add(0)
+	assert(len(reports), 1, "number of heals", t)
+	assert(errors.Is(reports[0].Cause, ErrCorruptTree), true, "report.Cause", t)
+	assert(reports[0].Lost, 0, "report.Lost", t)
+	assertSlice(tree.InOrderTraverse(), rangeWithSteps(0, 15, 1), "tree after self-healing Add", t)
+	assertBalanced(tree, "tree after self-healing Add", t)
+	assert(tree.Err(), nil, "tree.Err() after self-healing", t)
+}
@@ -0,0 +1,111 @@
+package avl
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Build a tree from a reader holding one value per line in ascending order,
+// such as a sorted run written by an external sort, parsing each line with
+// parse. Lines are read and added one at a time past the tree's maximum, so
+// apart from the tree itself memory use is bounded by the longest line, which
+// may be up to bufio.MaxScanTokenSize bytes.
+// Returns an error naming the line if a value can't be parsed or is out of
+// order, or if reading fails.
+func BuildFromSortedReader[T constraints.Ordered](r io.Reader, parse func([]byte) (T, error)) (*AvlTree[T], error) {
+	return BuildFromSortedReaders([]io.Reader{r}, parse)
+}
+
+// Build a tree from several readers like BuildFromSortedReader, merging their
+// sorted runs as they are read, so memory use apart from the tree is bounded
+// by one line per reader. Equal values from different readers are added in
+// the order of the readers.
+func BuildFromSortedReaders[T constraints.Ordered](readers []io.Reader, parse func([]byte) (T, error)) (*AvlTree[T], error) {
+	runs := make(runHeap[T], 0, len(readers))
+	for i, r := range readers {
+		run := &sortedRun[T]{index: i, scanner: bufio.NewScanner(r), parse: parse}
+		if run.advance() {
+			runs = append(runs, run)
+		}
+		if run.err != nil {
+			return nil, run.err
+		}
+	}
+	heap.Init(&runs)
+
+	tree := NewAvlTree[T]()
+	for len(runs) > 0 {
+		run := runs[0]
+		if err := tree.AppendMax(run.value); err != nil {
+			return nil, err
+		}
+		if run.advance() {
+			heap.Fix(&runs, 0)
+		} else {
+			heap.Pop(&runs)
+		}
+		if run.err != nil {
+			return nil, run.err
+		}
+	}
+	return tree, nil
+}
+
+// sortedRun reads the values of one sorted reader, one line at a time
+type sortedRun[T constraints.Ordered] struct {
+	index   int
+	scanner *bufio.Scanner
+	parse   func([]byte) (T, error)
+	// The current value and its line number
+	value T
+	line  int
+	err   error
+}
+
+// Read the next value, checking that it doesn't sort before the current one.
+// Returns false at the end of the reader or on an error, left in run.err.
+func (run *sortedRun[T]) advance() bool {
+	if !run.scanner.Scan() {
+		if err := run.scanner.Err(); err != nil {
+			run.err = fmt.Errorf("reader %d: %w", run.index, err)
+		}
+		return false
+	}
+	run.line += 1
+	v, err := run.parse(run.scanner.Bytes())
+	if err != nil {
+		run.err = fmt.Errorf("reader %d, line %d: %w", run.index, run.line, err)
+		return false
+	}
+	if run.line > 1 && v < run.value {
+		run.err = fmt.Errorf("reader %d, line %d: %w: %v follows %v", run.index, run.line, ErrInvalidArgument, v, run.value)
+		return false
+	}
+	run.value = v
+	return true
+}
+
+// runHeap is a min-heap of sorted runs by their current value, with ties
+// going to the earlier reader
+type runHeap[T constraints.Ordered] []*sortedRun[T]
+
+func (h runHeap[T]) Len() int { return len(h) }
+func (h runHeap[T]) Less(i, j int) bool {
+	if h[i].value != h[j].value {
+		return h[i].value < h[j].value
+	}
+	return h[i].index < h[j].index
+}
+func (h runHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *runHeap[T]) Push(x any)   { *h = append(*h, x.(*sortedRun[T])) }
+
+func (h *runHeap[T]) Pop() any {
+	old := *h
+	run := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return run
+}
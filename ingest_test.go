@@ -0,0 +1,56 @@
+package avl
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func parseInt(b []byte) (int, error) {
+	return strconv.Atoi(string(b))
+}
+
+// Test building a tree from one sorted reader
+func TestBuildFromSortedReader(t *testing.T) {
+	tree, err := BuildFromSortedReader(strings.NewReader("1\n2\n2\n5\n8\n"), parseInt)
+	assert(err, nil, "BuildFromSortedReader() error", t)
+	assertSlice(tree.InOrderTraverse(), []int{1, 2, 2, 5, 8}, "tree values", t)
+	assertBalanced(tree, "tree built from a reader", t)
+
+	tree, err = BuildFromSortedReader(strings.NewReader(""), parseInt)
+	assert(err, nil, "BuildFromSortedReader() of an empty reader", t)
+	assert(tree.Len(), 0, "tree.Len() of an empty reader", t)
+
+	_, err = BuildFromSortedReader(strings.NewReader("1\n3\n2\n"), parseInt)
+	assert(errors.Is(err, ErrInvalidArgument), true, "BuildFromSortedReader() of unsorted lines", t)
+	assert(strings.Contains(err.Error(), "line 3"), true, "error names the line: "+err.Error(), t)
+	_, err = BuildFromSortedReader(strings.NewReader("1\nx\n"), parseInt)
+	assert(errors.Is(err, strconv.ErrSyntax), true, "BuildFromSortedReader() of an unparsable line", t)
+}
+
+// Test merging several sorted readers
+func TestBuildFromSortedReaders(t *testing.T) {
+	readers := []io.Reader{
+		strings.NewReader("a 1\na 4\na 4\na 9\n"),
+		strings.NewReader(""),
+		strings.NewReader("c 0\nc 4\nc 10\n"),
+		strings.NewReader("d 2\n"),
+	}
+	// Tag each value with its reader; the tags sort after the values, which
+	// have the same width
+	parse := func(b []byte) (string, error) {
+		reader, value, _ := strings.Cut(string(b), " ")
+		n, err := strconv.Atoi(value)
+		return strconv.Itoa(100+n) + reader, err
+	}
+	tree, err := BuildFromSortedReaders(readers, parse)
+	assert(err, nil, "BuildFromSortedReaders() error", t)
+	assertSlice(tree.InOrderTraverse(), []string{"100c", "101a", "102d", "104a", "104a", "104c", "109a", "110c"}, "tree values", t)
+	assertBalanced(tree, "tree built from readers", t)
+
+	_, err = BuildFromSortedReaders([]io.Reader{strings.NewReader("1\n"), strings.NewReader("5\n4\n")}, parseInt)
+	assert(errors.Is(err, ErrInvalidArgument), true, "BuildFromSortedReaders() with an unsorted reader", t)
+	assert(strings.Contains(err.Error(), "reader 1, line 2"), true, "error names the reader and line: "+err.Error(), t)
+}
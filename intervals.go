@@ -0,0 +1,197 @@
+package avl
+
+import "iter"
+
+// CoalescingIntervalSet is a set of half-open intervals [lo, hi) that are
+// kept disjoint: inserting an interval merges it with every stored interval
+// it overlaps or touches, and removing one trims or splits the intervals it
+// overlaps. This is the usual structure for tracking which byte ranges of a
+// file have been downloaded or written, and which are still missing.
+//
+// Like RunSet, the set keeps the start of each interval in a tree and its end
+// in a map, so each operation takes O(log n) plus O(log n) per interval
+// merged or removed.
+type CoalescingIntervalSet[T Number] struct {
+	// Start of each interval
+	starts *AvlTree[T]
+	// End of the interval beginning at each start
+	ends  map[T]T
+	total T
+}
+
+// %% Public methods %%
+
+func NewCoalescingIntervalSet[T Number]() *CoalescingIntervalSet[T] {
+	return &CoalescingIntervalSet[T]{starts: NewAvlTree[T](), ends: make(map[T]T)}
+}
+
+// Add the interval [lo, hi) to the set, merging it with the stored intervals
+// it overlaps or touches. Does nothing if hi is not greater than lo.
+func (set *CoalescingIntervalSet[T]) Insert(lo, hi T) {
+	if !(lo < hi) {
+		return
+	}
+	// An interval ending at or after lo is merged
+	if node := set.starts.floorNode(lo); node != nil && set.ends[node.value] >= lo {
+		lo = node.value
+	}
+	// As is every interval starting up to hi
+	for node := set.starts.ceilingNode(lo); node != nil && node.value <= hi; node = set.starts.ceilingNode(lo) {
+		hi = max(hi, set.ends[node.value])
+		set.removeInterval(node.value)
+	}
+	set.addInterval(lo, hi)
+}
+
+// Remove the interval [lo, hi) from the set, trimming the stored intervals
+// that overlap it and splitting one that extends past it on both sides. Does
+// nothing if hi is not greater than lo.
+func (set *CoalescingIntervalSet[T]) Remove(lo, hi T) {
+	if !(lo < hi) {
+		return
+	}
+	// An interval starting before lo keeps its part before lo
+	if node := set.starts.lowerNode(lo); node != nil && set.ends[node.value] > lo {
+		start, end := node.value, set.ends[node.value]
+		set.removeInterval(start)
+		set.addInterval(start, lo)
+		if end > hi {
+			set.addInterval(hi, end)
+		}
+	}
+	// Intervals starting in [lo, hi) keep only their part from hi on
+	for node := set.starts.ceilingNode(lo); node != nil && node.value < hi; node = set.starts.ceilingNode(lo) {
+		end := set.ends[node.value]
+		set.removeInterval(node.value)
+		if end > hi {
+			set.addInterval(hi, end)
+		}
+	}
+}
+
+// Returns a bool indicating whether the point x lies in one of the set's
+// intervals
+func (set *CoalescingIntervalSet[T]) Covered(x T) bool {
+	node := set.starts.floorNode(x)
+	return node != nil && x < set.ends[node.value]
+}
+
+// Returns the total length of the set's intervals
+func (set *CoalescingIntervalSet[T]) TotalCovered() T {
+	return set.total
+}
+
+// Return the number of disjoint intervals the set is stored as
+func (set *CoalescingIntervalSet[T]) Len() int {
+	return set.starts.Len()
+}
+
+// Remove every interval from the set
+func (set *CoalescingIntervalSet[T]) Clear() {
+	set.starts.Clear()
+	clear(set.ends)
+	set.total = 0
+}
+
+// Returns an iterator over the set's intervals in ascending order, as the
+// start and end of each
+func (set *CoalescingIntervalSet[T]) Intervals() iter.Seq2[T, T] {
+	return func(yield func(T, T) bool) {
+		for node := set.starts.minNode(); node != nil; node = nextNode(node) {
+			if !yield(node.value, set.ends[node.value]) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over the maximal intervals of [lo, hi) not covered by
+// the set, in ascending order, as the start and end of each: the ranges still
+// missing from [lo, hi).
+func (set *CoalescingIntervalSet[T]) Gaps(lo, hi T) iter.Seq2[T, T] {
+	return func(yield func(T, T) bool) {
+		pos := lo
+		node := set.starts.floorNode(lo)
+		if node == nil {
+			node = set.starts.minNode()
+		}
+		for ; node != nil && pos < hi; node = nextNode(node) {
+			start, end := node.value, set.ends[node.value]
+			if end <= pos {
+				continue
+			}
+			if start > pos && !yield(pos, min(start, hi)) {
+				return
+			}
+			pos = end
+		}
+		if pos < hi {
+			yield(pos, hi)
+		}
+	}
+}
+
+// Returns the first window of at least minWidth not covered by the set that
+// starts at or after the given point, such as the next free slot of a
+// calendar whose busy times are the set's intervals. The window runs to the
+// start of the next interval; past the last interval the free space is
+// unbounded, so the window returned there is exactly minWidth wide.
+// Returns false if that window's end would overflow T.
+func (set *CoalescingIntervalSet[T]) NextFreeWindow(after, minWidth T) (lo, hi T, ok bool) {
+	pos := after
+	node := set.starts.floorNode(after)
+	if node == nil {
+		node = set.starts.minNode()
+	}
+	for ; node != nil; node = nextNode(node) {
+		start, end := node.value, set.ends[node.value]
+		if end <= pos {
+			continue
+		}
+		if start > pos && start-pos >= minWidth {
+			return pos, start, true
+		}
+		pos = end
+	}
+	hi = pos + max(minWidth, 0)
+	return pos, hi, hi >= pos
+}
+
+// %%% Private methods %%%
+
+// Returns a bool indicating whether any of the set's intervals overlaps
+// [lo, hi): the last one starting before hi does if it ends after lo
+func (set *CoalescingIntervalSet[T]) overlaps(lo, hi T) bool {
+	node := set.starts.lowerNode(hi)
+	return node != nil && set.ends[node.value] > lo
+}
+
+// Returns an iterator over the parts of the set's intervals that lie within
+// [lo, hi), in ascending order, as the start and end of each
+func (set *CoalescingIntervalSet[T]) clipped(lo, hi T) iter.Seq2[T, T] {
+	return func(yield func(T, T) bool) {
+		node := set.starts.floorNode(lo)
+		if node == nil {
+			node = set.starts.minNode()
+		}
+		for ; node != nil && node.value < hi; node = nextNode(node) {
+			start, end := max(node.value, lo), min(set.ends[node.value], hi)
+			if start < end && !yield(start, end) {
+				return
+			}
+		}
+	}
+}
+
+func (set *CoalescingIntervalSet[T]) addInterval(start, end T) {
+	set.starts.Add(start)
+	set.ends[start] = end
+	set.total += end - start
+}
+
+func (set *CoalescingIntervalSet[T]) removeInterval(start T) {
+	end := set.ends[start]
+	set.starts.Remove(start)
+	delete(set.ends, start)
+	set.total -= end - start
+}
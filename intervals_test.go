@@ -0,0 +1,116 @@
+package avl
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Collect the intervals of an iterator over pairs
+func collectIntervals[T Number](seq iter.Seq2[T, T]) [][2]T {
+	intervals := make([][2]T, 0)
+	for lo, hi := range seq {
+		intervals = append(intervals, [2]T{lo, hi})
+	}
+	return intervals
+}
+
+// Test that intervals are merged when inserted and split when removed
+func TestCoalescingIntervalSet(t *testing.T) {
+	set := NewCoalescingIntervalSet[int]()
+	set.Insert(10, 20)
+	set.Insert(30, 40)
+	set.Insert(20, 25) // touches [10, 20)
+	set.Insert(5, 5)   // empty
+	assertSlice(collectIntervals(set.Intervals()), [][2]int{{10, 25}, {30, 40}}, "set.Intervals()", t)
+	assert(set.TotalCovered(), 25, "set.TotalCovered()", t)
+
+	set.Insert(22, 35)
+	assertSlice(collectIntervals(set.Intervals()), [][2]int{{10, 40}}, "set.Intervals() after bridging", t)
+	assert(set.Covered(10), true, "set.Covered(10)", t)
+	assert(set.Covered(40), false, "set.Covered(40)", t)
+
+	set.Remove(15, 18)
+	set.Remove(35, 50)
+	assertSlice(collectIntervals(set.Intervals()), [][2]int{{10, 15}, {18, 35}}, "set.Intervals() after Remove", t)
+	assert(set.TotalCovered(), 22, "set.TotalCovered() after Remove", t)
+	assert(set.Len(), 2, "set.Len()", t)
+
+	assertSlice(collectIntervals(set.Gaps(0, 50)), [][2]int{{0, 10}, {15, 18}, {35, 50}}, "set.Gaps(0, 50)", t)
+	assertSlice(collectIntervals(set.Gaps(12, 20)), [][2]int{{15, 18}}, "set.Gaps(12, 20)", t)
+	assertSlice(collectIntervals(set.Gaps(20, 30)), [][2]int{}, "set.Gaps() of a covered range", t)
+
+	set.Clear()
+	assertSlice(collectIntervals(set.Gaps(0, 8)), [][2]int{{0, 8}}, "set.Gaps() after Clear", t)
+}
+
+// Test the set against a bitmap under random inserts and removals
+func TestCoalescingIntervalSetRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	set, model := NewCoalescingIntervalSet[int](), make([]bool, 200)
+	for i := 0; i < 5000; i++ {
+		lo := r.Intn(190)
+		hi := lo + r.Intn(10)
+		covered := r.Intn(2) == 0
+		if covered {
+			set.Insert(lo, hi)
+		} else {
+			set.Remove(lo, hi)
+		}
+		for x := lo; x < hi; x++ {
+			model[x] = covered
+		}
+	}
+
+	total := 0
+	for x, covered := range model {
+		assert(set.Covered(x), covered, "set.Covered()", t)
+		if covered {
+			total += 1
+		}
+	}
+	assert(set.TotalCovered(), total, "set.TotalCovered()", t)
+
+	// Intervals are maximal, and the gaps are exactly what they leave out
+	last := -1
+	for lo, hi := range set.Intervals() {
+		assert(lo > last, true, "intervals are separated", t)
+		last = hi
+	}
+	for lo, hi := range set.Gaps(0, len(model)) {
+		for x := lo; x < hi; x++ {
+			assert(model[x], false, "model at a gap", t)
+		}
+	}
+}
+
+// Test finding free windows between busy intervals and past the last one
+func TestNextFreeWindow(t *testing.T) {
+	busy := NewCoalescingIntervalSet[int]()
+	busy.Insert(9, 10)
+	busy.Insert(11, 12)
+	busy.Insert(13, 15)
+	busy.Insert(17, 18)
+	for _, tc := range []struct {
+		after, minWidth int
+		lo, hi          int
+	}{
+		{0, 1, 0, 9},
+		{9, 1, 10, 11},
+		{9, 2, 15, 17},
+		{14, 2, 15, 17},
+		{9, 3, 18, 21},
+		{20, 4, 20, 24},
+		{9, 0, 10, 11},
+	} {
+		lo, hi, ok := busy.NextFreeWindow(tc.after, tc.minWidth)
+		msg := fmt.Sprintf("busy.NextFreeWindow(%d, %d)", tc.after, tc.minWidth)
+		assert(ok, true, msg+" ok", t)
+		assert([2]int{lo, hi}, [2]int{tc.lo, tc.hi}, msg, t)
+	}
+
+	_, _, ok := busy.NextFreeWindow(math.MaxInt-1, 2)
+	assert(ok, false, "busy.NextFreeWindow() overflowing", t)
+}
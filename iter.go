@@ -0,0 +1,129 @@
+package avl
+
+import "iter"
+
+// Returns an iterator over the tree's values in ascending order, with equal
+// values in insertion order, for use with range:
+//
+//	for v := range tree.All() {
+//		fmt.Println(v)
+//	}
+//
+// The tree must not be modified during iteration; see AllWeak and ExtractIf.
+func (tree *AvlTree[T]) All() iter.Seq[T] {
+	return tree.walk(tree.minNode(), nextNode[T])
+}
+
+// Returns an iterator over the tree's values in descending order, with equal
+// values in reverse insertion order. The tree must not be modified during
+// iteration.
+func (tree *AvlTree[T]) Backward() iter.Seq[T] {
+	return tree.walk(tree.maxNode(), prevNode[T])
+}
+
+// Returns an iterator over the values from the start node on, moving to the
+// next node with step
+func (tree *AvlTree[T]) walk(start *Node[T], step func(*Node[T]) *Node[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for node := start; node != nil; node = step(node) {
+			value := node.value
+			if tree.clone != nil {
+				value = tree.clone(value)
+			}
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Returns a weakly consistent iterator over the tree's values in ascending
+// order. Unlike the other iterators, the tree may be modified while the
+// iteration is in progress, e.g. from the loop body: each step looks up the
+// next value after the last one yielded rather than following node links.
+//
+// Like sync.Map.Range, the iteration does not correspond to a consistent
+// snapshot, but it guarantees that:
+//   - every value present for the whole iteration is visited,
+//   - values are visited in ascending order, so no value is visited after a
+//     larger one, and a value is visited at most as many times as it is held,
+//   - values removed before they are reached are not visited, and values
+//     added ahead of the last visited value may or may not be.
+//
+// Each step costs O(log n).
+func (tree *AvlTree[T]) AllWeak() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		node := tree.minNode()
+		if node == nil {
+			return
+		}
+		value, seen, ok := node.value, 0, true
+		for ok && yield(value) {
+			value, seen, ok = tree.weakStep(value, seen+1)
+		}
+	}
+}
+
+// Returns the value AllWeak visits after one of which seen copies have been
+// visited, with the number of copies of it visited before, or false at the
+// end of the tree
+func (tree *AvlTree[T]) weakStep(value T, seen int) (T, int, bool) {
+	// Visit further copies of the value before moving past it
+	if node := tree.nodeAt(tree.CountLess(value) + seen); node != nil && tree.equal(node.value, value) {
+		return node.value, seen, true
+	}
+	node := tree.higherNode(value)
+	if node == nil {
+		var zero T
+		return zero, 0, false
+	}
+	return node.value, 0, true
+}
+
+// Returns an iterator that removes the values satisfying pred from the tree
+// and yields them in ascending order. Each value is removed before it is
+// yielded, so stopping the iteration early leaves the remaining matches in
+// the tree. Removals are recorded in the change log like a Remove.
+//
+// The iteration holds a cursor on the next node to test, which removals
+// don't move; the loop body must not otherwise modify the tree.
+func (tree *AvlTree[T]) ExtractIf(pred func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for node := tree.minNode(); node != nil; {
+			next := nextNode(node)
+			if pred(node.value) {
+				tree.removeNode(node)
+				if !yield(node.value) {
+					return
+				}
+			}
+			node = next
+		}
+	}
+}
+
+// Returns an iterator over the tree's values starting from the given value:
+// ascending from the first value greater than it, or descending from the last
+// value less than it. With inclusive set, values equal to it are included.
+// Equal values are visited in insertion order ascending and in reverse
+// insertion order descending. The tree must not be modified during
+// iteration.
+func (tree *AvlTree[T]) IterateFrom(value T, inclusive, descending bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		tree.walk(tree.startFrom(value, inclusive, descending))(yield)
+	}
+}
+
+// Returns the node IterateFrom starts at and the step to take from it
+func (tree *AvlTree[T]) startFrom(value T, inclusive, descending bool) (*Node[T], func(*Node[T]) *Node[T]) {
+	switch {
+	case !descending && inclusive:
+		return tree.ceilingNode(value), nextNode[T]
+	case !descending:
+		return tree.higherNode(value), nextNode[T]
+	case inclusive:
+		return tree.floorNode(value), prevNode[T]
+	default:
+		return tree.lowerNode(value), prevNode[T]
+	}
+}
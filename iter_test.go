@@ -0,0 +1,113 @@
+package avl
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Test that All and Backward visit the values in order and in reverse
+func TestAllBackward(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		expected := tree.InOrderTraverse()
+		assertSlice(slices.Collect(tree.All()), expected, "tree.All()", t)
+		slices.Reverse(expected)
+		assertSlice(slices.Collect(tree.Backward()), expected, "tree.Backward()", t)
+	}
+
+	tree := populateTree(t, rangeWithSteps(1, 10, 1))
+	values := make([]int, 0)
+	for v := range tree.Backward() {
+		if v == 7 {
+			break
+		}
+		values = append(values, v)
+	}
+	assertSlice(values, []int{10, 9, 8}, "tree.Backward() with break", t)
+	assert(len(slices.Collect(NewAvlTree[int]().All())), 0, "All() of an empty tree", t)
+}
+
+// Test that weak iteration matches in-order traversal without mutations
+func TestAllWeak(t *testing.T) {
+	for _, testCase := range append(slices.Clone(cases), []int{3, 1, 3, 2, 3}) {
+		tree := populateTree(t, testCase)
+		actual := make([]int, 0)
+		for v := range tree.AllWeak() {
+			actual = append(actual, v)
+		}
+		assertSlice(actual, tree.InOrderTraverse(), "tree.AllWeak()", t)
+	}
+}
+
+// Test weak iteration while the loop body modifies the tree
+func TestAllWeakWithMutations(t *testing.T) {
+	tree := populateTree(t, rangeWithSteps(0, 40, 2))
+	actual := make([]int, 0)
+	for v := range tree.AllWeak() {
+		actual = append(actual, v)
+		if v%2 == 0 {
+			tree.Remove(v)     // Removing the current value
+			tree.Remove(v + 4) // Removing a value not yet reached
+			tree.Add(v + 1)    // Adding a value ahead
+			tree.Add(v - 1)    // Adding a value behind
+		}
+	}
+
+	assertSlice(actual, []int{0, 1, 2, 3, 8, 9, 10, 11, 16, 17, 18, 19, 24, 25, 26, 27, 32, 33, 34, 35, 40, 41}, "tree.AllWeak() with mutations", t)
+}
+
+// Test that ExtractIf removes and yields the matching values, and leaves the
+// rest of the matches in place when stopped early
+func TestExtractIf(t *testing.T) {
+	tree := populateTree(t, []int{5, 3, 8, 3, 1, 9, 6, 4, 3})
+	even := func(v int) bool { return v%2 == 0 }
+	assertSlice(slices.Collect(tree.ExtractIf(even)), []int{4, 6, 8}, "tree.ExtractIf(even)", t)
+	assertSlice(tree.InOrderTraverse(), []int{1, 3, 3, 3, 5, 9}, "tree after ExtractIf(even)", t)
+	assertBalanced(tree, "tree after ExtractIf(even)", t)
+
+	extracted := make([]int, 0)
+	for v := range tree.ExtractIf(func(v int) bool { return v == 3 }) {
+		extracted = append(extracted, v)
+		if len(extracted) == 2 {
+			break
+		}
+	}
+	assertSlice(extracted, []int{3, 3}, "tree.ExtractIf() stopped early", t)
+	assertSlice(tree.InOrderTraverse(), []int{1, 3, 5, 9}, "tree after stopped ExtractIf()", t)
+
+	all := slices.Collect(tree.ExtractIf(func(int) bool { return true }))
+	assertSlice(all, []int{1, 3, 5, 9}, "tree.ExtractIf(all)", t)
+	assert(tree.IsEmpty(), true, "tree.IsEmpty() after ExtractIf(all)", t)
+
+	// Enough removals to rotate nodes around the cursor
+	tree = populateTree(t, rangeWithSteps(0, 300, 1))
+	thirds := func(v int) bool { return v%3 == 0 }
+	assertSlice(slices.Collect(tree.ExtractIf(thirds)), rangeWithSteps(0, 300, 3), "tree.ExtractIf(thirds)", t)
+	assert(tree.Len(), 200, "tree.Len() after ExtractIf(thirds)", t)
+	assertBalanced(tree, "tree after ExtractIf(thirds)", t)
+}
+
+// Test every combination of boundary and direction, from a held value and
+// from a value between held ones
+func TestIterateFrom(t *testing.T) {
+	tree := populateTree(t, []int{10, 20, 20, 30, 40})
+	for _, tc := range []struct {
+		from                  int
+		inclusive, descending bool
+		expected              []int
+	}{
+		{20, true, false, []int{20, 20, 30, 40}},
+		{20, false, false, []int{30, 40}},
+		{20, true, true, []int{20, 20, 10}},
+		{20, false, true, []int{10}},
+		{25, true, false, []int{30, 40}},
+		{25, false, true, []int{20, 20, 10}},
+		{40, false, false, nil},
+		{5, true, true, nil},
+		{50, false, true, []int{40, 30, 20, 20, 10}},
+	} {
+		actual := slices.Collect(tree.IterateFrom(tc.from, tc.inclusive, tc.descending))
+		assertSlice(actual, tc.expected, fmt.Sprintf("tree.IterateFrom(%d, %v, %v)", tc.from, tc.inclusive, tc.descending), t)
+	}
+}
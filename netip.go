@@ -0,0 +1,76 @@
+package avl
+
+import (
+	"math/bits"
+	"net/netip"
+)
+
+// Returns a tree of IP addresses ordered by netip.Addr.Less, so IPv4
+// addresses come before IPv6 ones.
+func NewAddrTree(opts ...TreeOption) *AvlTree[netip.Addr] {
+	return NewAvlTreeFunc(netip.Addr.Less, opts...)
+}
+
+// Returns a tree of IP prefixes ordered by PrefixLess.
+func NewPrefixTree(opts ...TreeOption) *AvlTree[netip.Prefix] {
+	return NewAvlTreeFunc(PrefixLess, opts...)
+}
+
+// Returns a bool indicating whether prefix a sorts before prefix b: by
+// masked address first, then by length, so a prefix comes right before the
+// longer prefixes it contains. Prefixes are compared as if masked, so
+// 10.1.2.3/8 and 10.0.0.0/8 are equal.
+func PrefixLess(a, b netip.Prefix) bool {
+	if c := a.Masked().Addr().Compare(b.Masked().Addr()); c != 0 {
+		return c < 0
+	}
+	return a.Bits() < b.Bits()
+}
+
+// Returns the longest prefix in the tree containing addr, and a bool
+// indicating whether one was found. The tree must be ordered by PrefixLess,
+// as trees from NewPrefixTree are. Each step takes the floor of addr and, if
+// it doesn't contain addr, retries with a prefix of addr shorter than the
+// bits they share, so a lookup takes O(log n) for each of at most as many
+// steps as addr has bits. IPv4-mapped IPv6 addresses are matched against
+// IPv6 prefixes; unmap them first to match IPv4 ones.
+func CoveringPrefix(tree *AvlTree[netip.Prefix], addr netip.Addr) (netip.Prefix, bool) {
+	if !addr.IsValid() {
+		return netip.Prefix{}, false
+	}
+	for k := addr.BitLen(); k >= 0; {
+		key, _ := addr.Prefix(k)
+		floor, ok := tree.Floor(key)
+		if !ok || floor.Addr().BitLen() != addr.BitLen() {
+			return netip.Prefix{}, false
+		}
+		if floor.Contains(addr) {
+			return floor, true
+		}
+		// A prefix containing addr and sorting before floor also contains
+		// floor, so it is no longer than the bits floor shares with addr
+		k = commonPrefixLen(floor.Masked().Addr(), addr)
+		if k >= floor.Bits() {
+			k = floor.Bits() - 1
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// Returns the number of leading bits two addresses of the same family share
+func commonPrefixLen(a, b netip.Addr) int {
+	x, y := a.As16(), b.As16()
+	n := 0
+	for i := range x {
+		if d := x[i] ^ y[i]; d != 0 {
+			n += bits.LeadingZeros8(d)
+			break
+		}
+		n += 8
+	}
+	if a.Is4() {
+		// As16 maps an IPv4 address into the last 32 of 128 bits
+		n -= 96
+	}
+	return n
+}
@@ -0,0 +1,59 @@
+package avl
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+func TestCoveringPrefix(t *testing.T) {
+	tree := NewPrefixTree()
+	for _, p := range []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.3.0.0/16", "192.168.0.0/16", "2001:db8::/32", "2001:db8:1::/48"} {
+		tree.Add(netip.MustParsePrefix(p))
+	}
+	tests := []struct {
+		addr, want string
+	}{
+		{"10.1.2.3", "10.1.2.0/24"},
+		{"10.1.3.1", "10.1.0.0/16"},
+		{"10.2.0.1", "10.0.0.0/8"},
+		{"10.3.255.255", "10.3.0.0/16"},
+		{"10.255.0.1", "10.0.0.0/8"},
+		{"192.168.5.5", "192.168.0.0/16"},
+		{"2001:db8:1::5", "2001:db8:1::/48"},
+		{"2001:db8:2::5", "2001:db8::/32"},
+		{"9.9.9.9", ""},
+		{"11.0.0.1", ""},
+		{"172.16.0.1", ""},
+		{"2001:db9::1", ""},
+		{"::1", ""},
+	}
+	for _, tt := range tests {
+		got, ok := CoveringPrefix(tree, netip.MustParseAddr(tt.addr))
+		if tt.want == "" {
+			assert(ok, false, fmt.Sprintf("%s should not be covered, got %s", tt.addr, got), t)
+			continue
+		}
+		assert(ok, true, fmt.Sprintf("%s should be covered", tt.addr), t)
+		assert(got, netip.MustParsePrefix(tt.want), fmt.Sprintf("longest prefix containing %s", tt.addr), t)
+	}
+	_, ok := CoveringPrefix(tree, netip.Addr{})
+	assert(ok, false, "an invalid address should not be covered", t)
+}
+
+func TestPrefixLess(t *testing.T) {
+	ordered := []string{"10.0.0.0/8", "10.0.0.0/16", "10.1.0.0/16", "11.0.0.0/8", "::/0", "2001:db8::/32"}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			pa, pb := netip.MustParsePrefix(a), netip.MustParsePrefix(b)
+			assert(PrefixLess(pa, pb), i < j, fmt.Sprintf("PrefixLess(%s, %s)", a, b), t)
+		}
+	}
+	assert(PrefixLess(netip.MustParsePrefix("10.1.2.3/8"), netip.MustParsePrefix("10.0.0.0/8")), false, "prefixes should compare masked", t)
+
+	addrs := NewAddrTree()
+	for _, a := range []string{"::1", "10.0.0.2", "10.0.0.1"} {
+		addrs.Add(netip.MustParseAddr(a))
+	}
+	assert(addrs.InOrderTraverse()[0], netip.MustParseAddr("10.0.0.1"), "IPv4 addresses should come first", t)
+}
@@ -0,0 +1,78 @@
+package avl
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// NilPolicy selects how a tree built with NewAvlTreeFunc treats nil values
+// of pointer, interface, slice, map, channel or function element types, so
+// that its less function doesn't have to. See WithNilPolicy.
+type NilPolicy int
+
+const (
+	// Pass nil values to the less function like any other. The default.
+	NilUnchecked NilPolicy = iota
+	// Refuse nil values: TryAdd, AppendMax, UpdateValue and Merge report
+	// ErrInvalidArgument through the misuse policy, and Add, which has no
+	// error to return, panics with it. Lookups of nil find nothing.
+	NilRejected
+	// Order nil values before all others
+	NilFirst
+	// Order nil values after all others
+	NilLast
+)
+
+// Handle nil values according to policy, wrapping the tree's less function
+// so it is never called with nil unless the policy is NilUnchecked. Nil
+// values are equal to each other. The option has no effect on element types
+// that can't be nil.
+//
+// Values moved between trees, by Graft, Union, Intersect or Difference, are
+// not checked again.
+func WithNilPolicy(policy NilPolicy) TreeOption {
+	return func(config *treeConfig) {
+		config.nilPolicy = policy
+	}
+}
+
+// %%% Tree private methods %%%
+
+// Wrap the tree's less function to order nil values by the policy
+func (tree *AvlTree[T]) applyNilPolicy(policy NilPolicy) {
+	if policy == NilUnchecked || !nilable(reflect.TypeFor[T]().Kind()) {
+		return
+	}
+	less := tree.less
+	// Rejected nils can still be looked up, so they need a place in the order
+	nilFirst := policy != NilLast
+	tree.less = func(a, b T) bool {
+		aNil, bNil := isNil(a), isNil(b)
+		if aNil || bNil {
+			return aNil != bNil && aNil == nilFirst
+		}
+		return less(a, b)
+	}
+	tree.rejectNil = policy == NilRejected
+}
+
+// Returns an error if the value may not be put in the tree
+func (tree *AvlTree[T]) checkValue(value T) error {
+	if tree.rejectNil && isNil(value) {
+		return fmt.Errorf("%w: nil values are rejected by the tree's nil policy", ErrInvalidArgument)
+	}
+	return nil
+}
+
+func nilable(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+func isNil[T any](value T) bool {
+	v := reflect.ValueOf(any(value))
+	return !v.IsValid() || nilable(v.Kind()) && v.IsNil()
+}
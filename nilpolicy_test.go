@@ -0,0 +1,80 @@
+package avl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNilPolicy(t *testing.T) {
+	ptrs := make([]*int, 5)
+	for i := range ptrs {
+		ptrs[i] = &i
+	}
+	// Dereferencing nil here would panic, so any nil must be kept from it
+	less := func(a, b *int) bool { return *a < *b }
+	values := func(tree *AvlTree[*int]) string {
+		s := ""
+		for _, p := range tree.InOrderTraverse() {
+			if p == nil {
+				s += "nil "
+			} else {
+				s += fmt.Sprint(*p, " ")
+			}
+		}
+		return s
+	}
+
+	first := NewAvlTreeFunc(less, WithNilPolicy(NilFirst))
+	last := NewAvlTreeFunc(less, WithNilPolicy(NilLast))
+	for _, p := range []*int{ptrs[3], nil, ptrs[1], nil, ptrs[4]} {
+		first.Add(p)
+		last.Add(p)
+	}
+	assert(values(first), "nil nil 1 3 4 ", "NilFirst order", t)
+	assert(values(last), "1 3 4 nil nil ", "NilLast order", t)
+	assert(first.Contains(nil), true, "NilFirst Contains(nil)", t)
+	assert(last.Remove(nil), true, "NilLast Remove(nil)", t)
+	assertBalanced(first, "NilFirst tree", t)
+
+	rejecting := NewAvlTreeFunc(less, WithNilPolicy(NilRejected))
+	rejecting.Add(ptrs[2])
+	err := rejecting.TryAdd(nil)
+	assert(errors.Is(err, ErrInvalidArgument), true, "TryAdd(nil) should be rejected", t)
+	err = rejecting.AppendMax(nil)
+	assert(errors.Is(err, ErrInvalidArgument), true, "AppendMax(nil) should be rejected", t)
+	err = rejecting.UpdateValue(rejecting.root, nil)
+	assert(errors.Is(err, ErrInvalidArgument), true, "UpdateValue(nil) should be rejected", t)
+	err = rejecting.Merge(SliceSource([]*int{nil, ptrs[3]}))
+	assert(errors.Is(err, ErrInvalidArgument), true, "Merge with nil should be rejected", t)
+	assert(panics(func() { rejecting.Add(nil) }), true, "Add(nil) should panic", t)
+	assert(rejecting.Contains(nil), false, "Contains(nil) should find nothing", t)
+	assert(values(rejecting), "2 ", "rejected values should not be added", t)
+
+	rejecting.SetMisusePolicy(PanicOnMisuse)
+	assert(panics(func() { rejecting.Merge(SliceSource([]*int{nil})) }), true,
+		"Merge with nil should panic under PanicOnMisuse", t)
+	assert(panics(func() { rejecting.Merge(SliceSource([]*int{ptrs[3], ptrs[1]})) }), true,
+		"Merge of an unsorted source should panic under PanicOnMisuse", t)
+	defer func() {
+		assert(recover() != nil, true, "TryAdd(nil) should panic under PanicOnMisuse", t)
+	}()
+	rejecting.TryAdd(nil)
+}
+
+// Test that interfaces holding nil pointers count as nil, and that the policy
+// is ignored for element types that can't be nil
+func TestNilPolicyTypes(t *testing.T) {
+	type stringer interface{ String() string }
+	var nilLabel *label
+	tree := NewAvlTreeFunc(func(a, b stringer) bool { return a.String() < b.String() }, WithNilPolicy(NilRejected))
+	assert(errors.Is(tree.TryAdd(nil), ErrInvalidArgument), true, "a nil interface should be rejected", t)
+	assert(errors.Is(tree.TryAdd(nilLabel), ErrInvalidArgument), true, "a nil pointer in an interface should be rejected", t)
+
+	ints := NewAvlTreeFunc(func(a, b int) bool { return a < b }, WithNilPolicy(NilRejected))
+	assert(ints.TryAdd(0), nil, "the zero value of a non-nilable type should be added", t)
+}
+
+type label struct{ s string }
+
+func (e *label) String() string { return e.s }
@@ -0,0 +1,115 @@
+package avl
+
+import "golang.org/x/exp/constraints"
+
+// Tag uniquely identifies a single Add performed by a replica of an ORSet.
+type Tag struct {
+	Replica string
+	Seq     uint64
+}
+
+// ORSet is an observed-remove set: a state-based CRDT whose replicas can be
+// modified independently and merged in any order. A Remove only cancels the
+// adds it has observed, so a concurrent Add of the same value on another
+// replica survives the merge. Elements are kept in an AvlTree so they can be
+// read back in order.
+type ORSet[T constraints.Ordered] struct {
+	replica    string
+	seq        uint64
+	elements   *AvlTree[T]
+	adds       map[T]map[Tag]struct{}
+	tombstones map[T]map[Tag]struct{}
+}
+
+// Returns a new, empty ORSet for the replica with the given unique id
+func NewORSet[T constraints.Ordered](replica string) *ORSet[T] {
+	return &ORSet[T]{
+		replica:    replica,
+		elements:   NewAvlTree[T](),
+		adds:       make(map[T]map[Tag]struct{}),
+		tombstones: make(map[T]map[Tag]struct{}),
+	}
+}
+
+// Add a value to the set, returning the tag that identifies this add
+func (set *ORSet[T]) Add(value T) Tag {
+	set.seq += 1
+	tag := Tag{Replica: set.replica, Seq: set.seq}
+	addTag(set.adds, value, tag)
+	set.sync(value)
+	return tag
+}
+
+// Remove a value from the set by tombstoning every add of it observed so far.
+// Returns true on successful removal, false if value was not in the set.
+func (set *ORSet[T]) Remove(value T) bool {
+	tags, ok := set.adds[value]
+	if !ok {
+		return false
+	}
+	for tag := range tags {
+		addTag(set.tombstones, value, tag)
+	}
+	delete(set.adds, value)
+	set.sync(value)
+	return true
+}
+
+// Returns a bool indicating whether the value exists in the set
+func (set *ORSet[T]) Contains(value T) bool {
+	return set.elements.Contains(value)
+}
+
+// Returns a slice of the set's values in-order
+func (set *ORSet[T]) Elements() []T {
+	return set.elements.InOrderTraverse()
+}
+
+// Return the number of values in the set
+func (set *ORSet[T]) Len() int {
+	return set.elements.Size()
+}
+
+// Merge the state of another replica into this one. Merge is commutative,
+// associative and idempotent, so replicas that have exchanged the same
+// states converge regardless of order or repetition.
+func (set *ORSet[T]) Merge(other *ORSet[T]) {
+	for value, tags := range other.tombstones {
+		for tag := range tags {
+			addTag(set.tombstones, value, tag)
+			if live, ok := set.adds[value]; ok {
+				delete(live, tag)
+				if len(live) == 0 {
+					delete(set.adds, value)
+				}
+			}
+		}
+		set.sync(value)
+	}
+	for value, tags := range other.adds {
+		for tag := range tags {
+			if _, removed := set.tombstones[value][tag]; !removed {
+				addTag(set.adds, value, tag)
+			}
+		}
+		set.sync(value)
+	}
+}
+
+// Make the element tree agree with the live tags of a value
+func (set *ORSet[T]) sync(value T) {
+	_, live := set.adds[value]
+	present := set.elements.Contains(value)
+	if live && !present {
+		set.elements.Add(value)
+	} else if !live && present {
+		set.elements.Remove(value)
+	}
+}
+
+func addTag[T constraints.Ordered](tags map[T]map[Tag]struct{}, value T, tag Tag) {
+	if tags[value] == nil {
+		tags[value] = make(map[Tag]struct{})
+	}
+	tags[value][tag] = struct{}{}
+}
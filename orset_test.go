@@ -0,0 +1,49 @@
+package avl
+
+import "testing"
+
+// Test that a concurrent add survives a remove on another replica
+func TestORSetAddWins(t *testing.T) {
+	a := NewORSet[string]("a")
+	b := NewORSet[string]("b")
+
+	a.Add("tahini")
+	b.Merge(a)
+
+	a.Remove("tahini")
+	b.Add("tahini")
+	b.Add("za'atar")
+
+	a.Merge(b)
+	b.Merge(a)
+
+	assertSlice(a.Elements(), []string{"tahini", "za'atar"}, "a.Elements() after Merge", t)
+	assertSlice(b.Elements(), a.Elements(), "b.Elements() after Merge", t)
+}
+
+// Test that merging is commutative and idempotent
+func TestORSetMergeConverges(t *testing.T) {
+	a := NewORSet[int]("a")
+	b := NewORSet[int]("b")
+	for _, v := range []int{5, 1, 3} {
+		a.Add(v)
+	}
+	b.Merge(a)
+	b.Remove(3)
+	b.Add(7)
+	a.Remove(1)
+
+	ab := NewORSet[int]("ab")
+	ab.Merge(a)
+	ab.Merge(b)
+
+	ba := NewORSet[int]("ba")
+	ba.Merge(b)
+	ba.Merge(a)
+	ba.Merge(a)
+
+	assertSlice(ab.Elements(), []int{5, 7}, "ab.Elements()", t)
+	assertSlice(ba.Elements(), ab.Elements(), "ba.Elements()", t)
+	assert(ab.Len(), 2, "ab.Len()", t)
+	assert(ab.Remove(1), false, "ab.Remove(1)", t)
+}
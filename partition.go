@@ -0,0 +1,302 @@
+package avl
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+// PartitionedTree routes values to a separate AvlTree per partition, as given
+// by a partition function such as a value's tenant or key prefix. Each
+// partition can be read or cleared on its own, while All still scans every
+// value in global order, e.g. for multi-tenant indexes.
+//
+// Partitions are created on first use and dropped once empty. The partition
+// function must return the same partition for equal values. A tree made by
+// NewRangePartitionedTree routes values by ranges instead, which Rebalance
+// can re-split.
+type PartitionedTree[P constraints.Ordered, T constraints.Ordered] struct {
+	partition func(T) P
+	// Keys of the non-empty partitions
+	keys  *AvlTree[P]
+	trees map[P]*AvlTree[T]
+	size  int
+	// For a tree partitioned by ranges, the least value of each range but
+	// the first
+	ranged bool
+	splits []T
+}
+
+// PartitionStats describes one partition of a PartitionedTree
+type PartitionStats[P constraints.Ordered] struct {
+	Partition P
+	// Number of values in the partition
+	Size int
+	// Height of the partition's tree, see AvlTree.Height
+	Height int
+}
+
+// %% Public methods %%
+
+func NewPartitionedTree[P constraints.Ordered, T constraints.Ordered](partition func(T) P) *PartitionedTree[P, T] {
+	return &PartitionedTree[P, T]{
+		partition: partition,
+		keys:      NewAvlTree[P](),
+		trees:     make(map[P]*AvlTree[T]),
+	}
+}
+
+// Returns a PartitionedTree whose partitions are consecutive ranges of
+// values, numbered from 0. Partition i holds the values from splits[i-1]
+// up to but not including splits[i], so the splits must be in strictly
+// ascending order. Unlike partitions given by a function, the ranges can be
+// re-split by Rebalance.
+func NewRangePartitionedTree[T constraints.Ordered](splits ...T) *PartitionedTree[int, T] {
+	pt := &PartitionedTree[int, T]{
+		keys:   NewAvlTree[int](),
+		trees:  make(map[int]*AvlTree[T]),
+		ranged: true,
+		splits: slices.Clone(splits),
+	}
+	pt.partition = func(value T) int {
+		i, found := slices.BinarySearch(pt.splits, value)
+		if found {
+			i += 1
+		}
+		return i
+	}
+	return pt
+}
+
+// Add the value to its partition, creating the partition if needed
+func (pt *PartitionedTree[P, T]) Add(value T) {
+	key := pt.partition(value)
+	tree, ok := pt.trees[key]
+	if !ok {
+		tree = NewAvlTree[T]()
+		pt.trees[key] = tree
+		pt.keys.Add(key)
+	}
+	tree.Add(value)
+	pt.size += 1
+}
+
+// Remove a value from its partition, dropping the partition if it becomes
+// empty. Returns true on successful removal, false if value was not found.
+func (pt *PartitionedTree[P, T]) Remove(value T) bool {
+	key := pt.partition(value)
+	tree, ok := pt.trees[key]
+	if !ok || !tree.Remove(value) {
+		return false
+	}
+	pt.size -= 1
+	if tree.IsEmpty() {
+		pt.dropPartition(key)
+	}
+	return true
+}
+
+// Returns a bool indicating whether the value exists in its partition
+func (pt *PartitionedTree[P, T]) Contains(value T) bool {
+	tree, ok := pt.trees[pt.partition(value)]
+	return ok && tree.Contains(value)
+}
+
+// Return the number of values across all partitions
+func (pt *PartitionedTree[P, T]) Len() int {
+	return pt.size
+}
+
+// Return the number of non-empty partitions
+func (pt *PartitionedTree[P, T]) PartitionCount() int {
+	return pt.keys.Len()
+}
+
+// Returns a read-only view of the partition's tree, and false if the
+// partition is empty
+func (pt *PartitionedTree[P, T]) Partition(key P) (ReadOnlyTree[T], bool) {
+	tree, ok := pt.trees[key]
+	if !ok {
+		return ReadOnlyTree[T]{}, false
+	}
+	return tree.ReadOnly(), true
+}
+
+// Remove every value in the partition.
+// Returns the number of values removed.
+func (pt *PartitionedTree[P, T]) ClearPartition(key P) int {
+	tree, ok := pt.trees[key]
+	if !ok {
+		return 0
+	}
+	n := tree.Len()
+	pt.size -= n
+	pt.dropPartition(key)
+	return n
+}
+
+// Clear every partition
+func (pt *PartitionedTree[P, T]) Clear() {
+	pt.keys.Clear()
+	clear(pt.trees)
+	pt.size = 0
+}
+
+// Returns an iterator over the keys of the non-empty partitions in ascending
+// order
+func (pt *PartitionedTree[P, T]) Partitions() iter.Seq[P] {
+	return func(yield func(P) bool) {
+		for node := pt.keys.minNode(); node != nil; node = nextNode(node) {
+			if !yield(node.value) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over the values of every partition in ascending order.
+// The partitions' values are merged as they are consumed, so each step costs
+// O(log p) for p partitions, whatever the partition function.
+func (pt *PartitionedTree[P, T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		cursors := make(nodeHeap[T], 0, len(pt.trees))
+		for key := range pt.Partitions() {
+			cursors = append(cursors, pt.trees[key].minNode())
+		}
+		heap.Init(&cursors)
+		for len(cursors) > 0 {
+			node := cursors[0]
+			if !yield(node.value) {
+				return
+			}
+			if next := nextNode(node); next != nil {
+				cursors[0] = next
+				heap.Fix(&cursors, 0)
+			} else {
+				heap.Pop(&cursors)
+			}
+		}
+	}
+}
+
+// Returns the size and height of every non-empty partition, in ascending
+// order of partition key
+func (pt *PartitionedTree[P, T]) Stats() []PartitionStats[P] {
+	stats := make([]PartitionStats[P], 0, len(pt.trees))
+	for key := range pt.Partitions() {
+		tree := pt.trees[key]
+		stats = append(stats, PartitionStats[P]{Partition: key, Size: tree.Len(), Height: tree.Height()})
+	}
+	return stats
+}
+
+// Return the size of the largest partition divided by the mean partition
+// size: 1 when values are spread evenly, and up to the number of partitions
+// when one partition holds them all. Returns 0 for an empty tree.
+func (pt *PartitionedTree[P, T]) Skew() float64 {
+	if pt.size == 0 {
+		return 0
+	}
+	largest := 0
+	for _, tree := range pt.trees {
+		largest = max(largest, tree.Len())
+	}
+	return float64(largest) * float64(len(pt.trees)) / float64(pt.size)
+}
+
+// Re-split the ranges of a tree made by NewRangePartitionedTree by rank, so
+// that each partition holds about as many values as the others, e.g. to fix
+// a hot partition. Equal values stay in the same partition, so a partition
+// can hold more than its share, and ranges left over when there are fewer
+// distinct values than partitions are dropped. Each partition is rebuilt
+// perfectly balanced, in O(n) for n values overall.
+// Returns the number of values that moved to another partition.
+// Returns errors.ErrUnsupported for a tree partitioned by a function, whose
+// partitions each hold exactly the values the function maps to them.
+func (pt *PartitionedTree[P, T]) Rebalance() (int, error) {
+	if !pt.ranged {
+		return 0, fmt.Errorf("rebalancing partitions given by a function: %w", errors.ErrUnsupported)
+	}
+	if pt.size == 0 {
+		return 0, nil
+	}
+	values := slices.Collect(pt.All())
+	ranges := len(pt.splits) + 1
+
+	// Start each range at its share of the ranks, moved past any copies of
+	// the last value of the range before
+	starts := make([]int, 0, ranges)
+	for i := range ranges {
+		start := i * len(values) / ranges
+		if i > 0 {
+			start = max(start, starts[i-1]+1)
+			for start < len(values) && values[start] == values[start-1] {
+				start += 1
+			}
+		}
+		if start >= len(values) {
+			break
+		}
+		starts = append(starts, start)
+	}
+
+	previous := make([]P, len(values))
+	for i, v := range values {
+		previous[i] = pt.partition(v)
+	}
+	pt.splits = pt.splits[:0]
+	for _, start := range starts[1:] {
+		pt.splits = append(pt.splits, values[start])
+	}
+
+	moved := 0
+	keys := make([]P, 0, len(starts))
+	trees := make(map[P]*AvlTree[T], len(starts))
+	for i, start := range starts {
+		end := len(values)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		key := pt.partition(values[start])
+		for _, p := range previous[start:end] {
+			if p != key {
+				moved += 1
+			}
+		}
+		tree := NewAvlTree[T]()
+		tree.replaceValues(values[start:end])
+		keys = append(keys, key)
+		trees[key] = tree
+	}
+
+	pt.trees = trees
+	pt.keys.replaceValues(keys)
+	return moved, nil
+}
+
+// %%% Private methods %%%
+
+func (pt *PartitionedTree[P, T]) dropPartition(key P) {
+	delete(pt.trees, key)
+	pt.keys.Remove(key)
+}
+
+// nodeHeap is a min-heap of nodes by value, for merging the in-order
+// traversals of several trees
+type nodeHeap[T constraints.Ordered] []*Node[T]
+
+func (h nodeHeap[T]) Len() int           { return len(h) }
+func (h nodeHeap[T]) Less(i, j int) bool { return h[i].value < h[j].value }
+func (h nodeHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap[T]) Push(x any)        { *h = append(*h, x.(*Node[T])) }
+
+func (h *nodeHeap[T]) Pop() any {
+	old := *h
+	node := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return node
+}
@@ -0,0 +1,143 @@
+package avl
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func tenantOf(value string) string {
+	return value[:1]
+}
+
+// Test that values are routed to their partitions and scanned in global order
+func TestPartitionedTree(t *testing.T) {
+	pt := NewPartitionedTree(tenantOf)
+	for _, v := range []string{"b2", "a9", "c1", "a1", "b1", "a5", "a5"} {
+		pt.Add(v)
+	}
+	assert(pt.Len(), 7, "pt.Len()", t)
+	assert(pt.PartitionCount(), 3, "pt.PartitionCount()", t)
+	assertSlice(slices.Collect(pt.Partitions()), []string{"a", "b", "c"}, "pt.Partitions()", t)
+	assertSlice(slices.Collect(pt.All()), []string{"a1", "a5", "a5", "a9", "b1", "b2", "c1"}, "pt.All()", t)
+	assert(pt.Contains("b1"), true, "pt.Contains(\"b1\")", t)
+	assert(pt.Contains("d1"), false, "pt.Contains(\"d1\")", t)
+
+	a, ok := pt.Partition("a")
+	assert(ok, true, "pt.Partition(\"a\") ok", t)
+	assertSlice(a.InOrderTraverse(), []string{"a1", "a5", "a5", "a9"}, "pt.Partition(\"a\")", t)
+
+	assert(pt.Remove("c1"), true, "pt.Remove(\"c1\")", t)
+	assert(pt.Remove("c1"), false, "pt.Remove(\"c1\") again", t)
+	_, ok = pt.Partition("c")
+	assert(ok, false, "pt.Partition(\"c\") after removing its last value", t)
+
+	assert(pt.ClearPartition("a"), 4, "pt.ClearPartition(\"a\")", t)
+	assert(pt.ClearPartition("a"), 0, "pt.ClearPartition(\"a\") again", t)
+	assertSlice(slices.Collect(pt.All()), []string{"b1", "b2"}, "pt.All() after ClearPartition", t)
+	assert(pt.Len(), 2, "pt.Len() after ClearPartition", t)
+
+	pt.Clear()
+	assert(pt.Len(), 0, "pt.Len() after Clear", t)
+	assert(len(slices.Collect(pt.All())), 0, "pt.All() after Clear", t)
+}
+
+// Test that the global scan merges partitions whose values interleave
+func TestPartitionedTreeInterleaved(t *testing.T) {
+	pt := NewPartitionedTree(func(v int) int { return v % 3 })
+	for _, v := range []int{9, 4, 7, 0, 2, 5, 8, 1, 6, 3} {
+		pt.Add(v)
+	}
+	assertSlice(slices.Collect(pt.All()), rangeWithSteps(0, 9, 1), "pt.All()", t)
+
+	values := make([]int, 0)
+	for v := range pt.All() {
+		if v == 4 {
+			break
+		}
+		values = append(values, v)
+	}
+	assertSlice(values, []int{0, 1, 2, 3}, "pt.All() with break", t)
+}
+
+// Test that statistics describe each partition
+func TestPartitionedTreeStats(t *testing.T) {
+	pt := NewPartitionedTree(func(v int) int { return v / 100 })
+	assert(pt.Skew(), 0.0, "pt.Skew() of an empty tree", t)
+	for v := 0; v < 8; v++ {
+		pt.Add(v)
+	}
+	pt.Remove(0) // Leaves 7 values in a tree of height 3
+	pt.Add(100)
+
+	stats := pt.Stats()
+	assert(len(stats), 2, "len(pt.Stats())", t)
+	assert(stats[0], PartitionStats[int]{Partition: 0, Size: 7, Height: 3}, "pt.Stats()[0]", t)
+	assert(stats[1], PartitionStats[int]{Partition: 1, Size: 1, Height: 0}, "pt.Stats()[1]", t)
+	assert(pt.Skew(), 7.0*2/8, "pt.Skew()", t)
+}
+
+// Test that rebalancing re-splits the ranges by rank and routes new values
+// by the resulting ranges
+func TestPartitionedTreeRebalance(t *testing.T) {
+	pt := NewRangePartitionedTree(100)
+	moved, err := pt.Rebalance()
+	assert(moved, 0, "pt.Rebalance() of an empty tree", t)
+	assert(err, nil, "pt.Rebalance() error of an empty tree", t)
+	for _, v := range append(rangeWithSteps(1, 7, 1), 100) {
+		pt.Add(v)
+	}
+	stats := pt.Stats()
+	assert(stats[0], PartitionStats[int]{Partition: 0, Size: 7, Height: 2}, "pt.Stats()[0]", t)
+
+	moved, _ = pt.Rebalance()
+	assert(moved, 3, "pt.Rebalance()", t)
+	stats = pt.Stats()
+	assert(stats[0], PartitionStats[int]{Partition: 0, Size: 4, Height: 2}, "pt.Stats()[0] after Rebalance", t)
+	assert(stats[1], PartitionStats[int]{Partition: 1, Size: 4, Height: 2}, "pt.Stats()[1] after Rebalance", t)
+	assert(pt.Skew(), 1.0, "pt.Skew() after Rebalance", t)
+	moved, _ = pt.Rebalance()
+	assert(moved, 0, "pt.Rebalance() again", t)
+
+	view, _ := pt.Partition(1)
+	assertSlice(view.InOrderTraverse(), []int{5, 6, 7, 100}, "partition 1 after Rebalance", t)
+	pt.Add(8)
+	pt.Add(0)
+	assert(pt.Contains(6), true, "pt.Contains(6) after Rebalance", t)
+	assert(pt.Remove(6), true, "pt.Remove(6) after Rebalance", t)
+	view, _ = pt.Partition(0)
+	assertSlice(view.InOrderTraverse(), []int{0, 1, 2, 3, 4}, "partition 0 after Add", t)
+	view, _ = pt.Partition(1)
+	assertSlice(view.InOrderTraverse(), []int{5, 7, 8, 100}, "partition 1 after Add", t)
+	assertSlice(slices.Collect(pt.All()), []int{0, 1, 2, 3, 4, 5, 7, 8, 100}, "pt.All() after Rebalance", t)
+
+	// Clearing a partition clears its range
+	assert(pt.ClearPartition(0), 5, "pt.ClearPartition(0) after Rebalance", t)
+	assertSlice(slices.Collect(pt.All()), []int{5, 7, 8, 100}, "pt.All() after ClearPartition", t)
+
+	// Equal values stay in one partition
+	dups := NewRangePartitionedTree(100)
+	for _, v := range []int{1, 1, 1, 1, 2, 100} {
+		dups.Add(v)
+	}
+	moved, _ = dups.Rebalance()
+	assert(moved, 1, "dups.Rebalance()", t)
+	view, _ = dups.Partition(0)
+	assertSlice(view.InOrderTraverse(), []int{1, 1, 1, 1}, "partition 0 of equal values", t)
+}
+
+// Test that partitions given by a function are never re-split, so each
+// tenant keeps its own partition
+func TestPartitionedTreeRebalanceByFunction(t *testing.T) {
+	pt := NewPartitionedTree(tenantOf)
+	for _, v := range []string{"a1", "a2", "a3", "b1"} {
+		pt.Add(v)
+	}
+	moved, err := pt.Rebalance()
+	assert(moved, 0, "pt.Rebalance()", t)
+	assert(errors.Is(err, errors.ErrUnsupported), true, "pt.Rebalance() error is ErrUnsupported", t)
+
+	a, _ := pt.Partition("a")
+	assertSlice(a.InOrderTraverse(), []string{"a1", "a2", "a3"}, "partition a after Rebalance", t)
+	assert(pt.ClearPartition("b"), 1, "pt.ClearPartition(\"b\")", t)
+}
@@ -0,0 +1,30 @@
+package avl
+
+// MisusePolicy selects how a tree reports misuse by its caller: arguments
+// that can never be valid, such as nil node handles, handles from another
+// tree or to values that were already removed, or ranges with reversed
+// bounds. Conditions that depend on the tree's contents, such as popping from
+// an empty tree, are always reported as errors.
+type MisusePolicy int
+
+const (
+	// Return misuse as an error from the method called. The default, for
+	// libraries that pass caller input through.
+	ReturnMisuseErrors MisusePolicy = iota
+	// Panic with the error instead, for applications that treat misuse as a
+	// bug and would rather fail at the call than check every error.
+	PanicOnMisuse
+)
+
+// Set how the tree reports misuse. See MisusePolicy.
+func (tree *AvlTree[T]) SetMisusePolicy(policy MisusePolicy) {
+	tree.misusePolicy = policy
+}
+
+// Report misuse according to the tree's policy: panic with err, or return it
+func (tree *AvlTree[T]) misuse(err error) error {
+	if tree.misusePolicy == PanicOnMisuse {
+		panic(err)
+	}
+	return err
+}
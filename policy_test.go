@@ -0,0 +1,36 @@
+package avl
+
+import "testing"
+
+// Returns whether fn panicked
+func panics(fn func()) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	fn()
+	return false
+}
+
+// Test that misuse is returned as an error by default and panics when the
+// policy asks for it, while errors about the tree's contents never panic
+func TestMisusePolicy(t *testing.T) {
+	tree := populateTree(t, []int{1, 2, 3})
+	_, err := tree.DetachSubtree(nil)
+	assert(err != nil, true, "tree.DetachSubtree(nil) error", t)
+
+	tree.SetMisusePolicy(PanicOnMisuse)
+	assert(panics(func() { tree.DetachSubtree(nil) }), true, "tree.DetachSubtree(nil) panics", t)
+	assert(panics(func() { tree.Graft(tree) }), true, "tree.Graft(tree) panics", t)
+	assert(panics(func() { tree.RotateLeftAt(tree.FindNode(3)) }), true, "tree.RotateLeftAt(leaf) panics", t)
+
+	tree.Clear()
+	assert(panics(func() { tree.PopMin() }), false, "tree.PopMin() on empty tree panics", t)
+
+	pq := NewPriorityQueue[int, string]()
+	handle, _ := pq.Push(1, "a")
+	pq.PopMin()
+	assert(pq.UpdatePriority(handle, 2) != nil, true, "pq.UpdatePriority() of a popped value error", t)
+	pq.SetMisusePolicy(PanicOnMisuse)
+	assert(panics(func() { pq.UpdatePriority(handle, 2) }), true, "pq.UpdatePriority() of a popped value panics", t)
+	assert(panics(func() { pq.UpdatePriority(nil, 2) }), true, "pq.UpdatePriority(nil) panics", t)
+}
@@ -0,0 +1,143 @@
+package avl
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/constraints"
+)
+
+// PriorityQueue is a min-priority queue of values of type T with priorities
+// of type P, backed by an AvlTree. Values with equal priority are popped in
+// the order they were pushed, and unlike a heap the queued values can be read
+// back in priority order with Values.
+type PriorityQueue[P constraints.Ordered, T any] struct {
+	tree *AvlTree[*QueueHandle[P, T]]
+}
+
+// QueueHandle refers to a value pushed onto a PriorityQueue, and can be used
+// to change the value's priority while it is queued.
+type QueueHandle[P constraints.Ordered, T any] struct {
+	priority P
+	value    T
+	// The handle's node in the queue's tree, or nil once popped
+	node *Node[*QueueHandle[P, T]]
+}
+
+// Returns a new, empty priority queue
+func NewPriorityQueue[P constraints.Ordered, T any]() *PriorityQueue[P, T] {
+	return &PriorityQueue[P, T]{
+		tree: NewAvlTreeFunc(func(a, b *QueueHandle[P, T]) bool { return a.priority < b.priority }),
+	}
+}
+
+// Add a value to the queue with the given priority, behind any values
+// already queued with the same priority.
+// Returns a handle to the queued value, or an error wrapping
+// ErrInvalidArgument if the priority is NaN, which has no place in the order.
+func (pq *PriorityQueue[P, T]) Push(priority P, value T) (*QueueHandle[P, T], error) {
+	if err := pq.checkPriority(priority); err != nil {
+		return nil, err
+	}
+	handle := &QueueHandle[P, T]{priority: priority, value: value}
+	node, err := pq.tree.addNode(handle)
+	if err != nil {
+		return nil, err
+	}
+	handle.node = node
+	return handle, nil
+}
+
+// Remove and return the value with the minimum priority in the queue, and
+// its priority
+func (pq *PriorityQueue[P, T]) PopMin() (T, P, error) {
+	handle, err := pq.tree.PopMin()
+	if err != nil {
+		var value T
+		var priority P
+		return value, priority, ErrEmptyTree
+	}
+	handle.node = nil
+	return handle.value, handle.priority, nil
+}
+
+// Return the value with the minimum priority in the queue, and its priority,
+// without removing it
+func (pq *PriorityQueue[P, T]) PeekMin() (T, P, error) {
+	handle, err := pq.tree.GetMin()
+	if err != nil {
+		var value T
+		var priority P
+		return value, priority, ErrEmptyTree
+	}
+	return handle.value, handle.priority, nil
+}
+
+// Set how the queue reports misuse, such as updating a handle whose value
+// was already popped. See MisusePolicy.
+func (pq *PriorityQueue[P, T]) SetMisusePolicy(policy MisusePolicy) {
+	pq.tree.SetMisusePolicy(policy)
+}
+
+// Return the number of values in the queue
+func (pq *PriorityQueue[P, T]) Len() int {
+	return pq.tree.Size()
+}
+
+// Returns a slice of the queued values in priority order
+func (pq *PriorityQueue[P, T]) Values() []T {
+	values := make([]T, 0, pq.tree.Size())
+	for handle := range pq.tree.All() {
+		values = append(values, handle.value)
+	}
+	return values
+}
+
+// Change the priority of a queued value. The value is queued behind any
+// values already queued with the new priority.
+// Returns an error wrapping ErrInvalidNode if the handle is nil, one if the
+// handle's value has already been popped or belongs to another queue, or one
+// wrapping ErrInvalidArgument if the priority is NaN.
+func (pq *PriorityQueue[P, T]) UpdatePriority(handle *QueueHandle[P, T], priority P) error {
+	if handle == nil {
+		return pq.tree.misuse(fmt.Errorf("handle is nil: %w", ErrInvalidNode))
+	}
+	if handle.node == nil || !pq.tree.ownsNode(handle.node) {
+		return pq.tree.misuse(fmt.Errorf("value is not queued: %w", ErrNotFound))
+	}
+	if err := pq.checkPriority(priority); err != nil {
+		return err
+	}
+	pq.tree.removeNode(handle.node)
+	handle.priority = priority
+	node, err := pq.tree.addNode(handle)
+	if err != nil {
+		handle.node = nil
+		return err
+	}
+	handle.node = node
+	return nil
+}
+
+// Returns the value the handle refers to
+func (handle *QueueHandle[P, T]) Value() T {
+	return handle.value
+}
+
+// Returns the priority the handle's value is queued with
+func (handle *QueueHandle[P, T]) Priority() P {
+	return handle.priority
+}
+
+// Returns a bool indicating whether the handle's value is still queued
+func (handle *QueueHandle[P, T]) Queued() bool {
+	return handle.node != nil
+}
+
+// Reject NaN priorities, which compare neither less than nor greater than
+// any other and would be equal to every priority
+func (pq *PriorityQueue[P, T]) checkPriority(priority P) error {
+	if priority != priority {
+		return pq.tree.misuse(fmt.Errorf("%w: priority is NaN", ErrInvalidArgument))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package avl
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// Test that equal priorities pop in push order and handles track values
+func TestPriorityQueue(t *testing.T) {
+	pq := NewPriorityQueue[int, string]()
+	_, _, err := pq.PopMin()
+	assert(err != nil, true, "pq.PopMin() on empty queue", t)
+
+	first, _ := pq.Push(5, "first")
+	pq.Push(3, "three")
+	second, _ := pq.Push(5, "second")
+	pq.Push(8, "eight")
+	assert(pq.Len(), 4, "pq.Len()", t)
+
+	value, priority, _ := pq.PeekMin()
+	assert(value, "three", "pq.PeekMin() value", t)
+	assert(priority, 3, "pq.PeekMin() priority", t)
+
+	// Moving the first 5 to 5 again puts it behind the second 5
+	assert(pq.UpdatePriority(first, 5), nil, "pq.UpdatePriority(first, 5)", t)
+	assertSlice(pq.Values(), []string{"three", "second", "first", "eight"}, "pq.Values() after requeueing first", t)
+	assert(pq.UpdatePriority(second, 1), nil, "pq.UpdatePriority(second, 1)", t)
+	assert(second.Priority(), 1, "second.Priority()", t)
+	assertSlice(pq.Values(), []string{"second", "three", "first", "eight"}, "pq.Values()", t)
+
+	for _, expected := range []string{"second", "three", "first"} {
+		v, _, err := pq.PopMin()
+		assert(err, nil, "pq.PopMin()", t)
+		assert(v, expected, "pq.PopMin()", t)
+	}
+	assert(first.Queued(), false, "first.Queued() after popping it", t)
+	assert(pq.UpdatePriority(first, 10) != nil, true, "pq.UpdatePriority(popped)", t)
+	assert(pq.Len(), 1, "pq.Len() after PopMin", t)
+
+	other := NewPriorityQueue[int, string]()
+	foreign, _ := other.Push(1, "foreign")
+	assert(errors.Is(pq.UpdatePriority(foreign, 2), ErrNotFound), true, "pq.UpdatePriority() of another queue's handle", t)
+	assert(errors.Is(pq.UpdatePriority(nil, 2), ErrInvalidNode), true, "pq.UpdatePriority(nil)", t)
+	assertBalanced(pq.tree, "queue tree", t)
+}
+
+// Test that values pushed with equal priorities pop in push order
+func TestPriorityQueueStableTies(t *testing.T) {
+	pq := NewPriorityQueue[float64, int]()
+	for i := 0; i < 100; i++ {
+		pq.Push(float64(i%3), i)
+	}
+	prevPriority, prevValue := -1.0, -1
+	for pq.Len() > 0 {
+		value, priority, _ := pq.PopMin()
+		if priority == prevPriority && value < prevValue {
+			t.Fatalf("%d popped after %d with the same priority %v", value, prevValue, priority)
+		}
+		prevPriority, prevValue = priority, value
+	}
+}
+
+// Test that NaN priorities are rejected, as they have no place in the order
+func TestPriorityQueueNaN(t *testing.T) {
+	pq := NewPriorityQueue[float64, string]()
+	_, err := pq.Push(math.NaN(), "nan")
+	assert(errors.Is(err, ErrInvalidArgument), true, "pq.Push(NaN)", t)
+	assert(pq.Len(), 0, "pq.Len() after pushing NaN", t)
+
+	handle, _ := pq.Push(1, "one")
+	assert(errors.Is(pq.UpdatePriority(handle, math.NaN()), ErrInvalidArgument), true, "pq.UpdatePriority(NaN)", t)
+	assert(handle.Queued(), true, "handle.Queued() after a rejected update", t)
+	assert(handle.Priority(), 1.0, "handle.Priority() after a rejected update", t)
+	pq.Push(math.Inf(-1), "-inf")
+	value, _, _ := pq.PopMin()
+	assert(value, "-inf", "infinite priorities are ordered", t)
+}
@@ -0,0 +1,81 @@
+package avl
+
+import (
+	"fmt"
+	"io"
+)
+
+// PrintFormat selects how Print lays out the tree.
+type PrintFormat int
+
+const (
+	// One value per line, in order
+	PrintSorted PrintFormat = iota
+	// The tree's structure, one node per line with children indented below
+	// their parent and marked as the left (L) or right (R) child
+	PrintStructure
+	// Like PrintStructure, with each node's height and balance factor
+	PrintBalance
+)
+
+// PrintOption configures Print.
+type PrintOption func(*printConfig)
+
+type printConfig struct {
+	format PrintFormat
+}
+
+// Print using the given format. The default format is PrintSorted.
+func WithFormat(format PrintFormat) PrintOption {
+	return func(config *printConfig) {
+		config.format = format
+	}
+}
+
+// Write the tree to w in the format selected by the options.
+// Returns the first error encountered writing to w.
+func (tree *AvlTree[T]) Print(w io.Writer, opts ...PrintOption) error {
+	config := printConfig{format: PrintSorted}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.format == PrintSorted {
+		for node := tree.minNode(); node != nil; node = nextNode(node) {
+			if _, err := fmt.Fprintln(w, node.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if tree.root == nil {
+		return nil
+	}
+	return printStructure(w, tree.root, "", "", config.format == PrintBalance)
+}
+
+// Write the node on a line starting with label, and its children below it,
+// each line of the children starting with prefix
+func printStructure[T any](w io.Writer, node *Node[T], label, prefix string, annotate bool) error {
+	line := fmt.Sprintf("%s%v", label, node.value)
+	if annotate {
+		line += fmt.Sprintf(" (h=%d bf=%+d)", subtreeHeight(node), node.balanceFactor())
+	}
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+
+	if node.children[dirLeft] != nil {
+		branch, indent := "├── ", "│   "
+		if node.children[dirRight] == nil {
+			branch, indent = "└── ", "    "
+		}
+		if err := printStructure(w, node.children[dirLeft], prefix+branch+"L: ", prefix+indent, annotate); err != nil {
+			return err
+		}
+	}
+	if node.children[dirRight] != nil {
+		return printStructure(w, node.children[dirRight], prefix+"└── R: ", prefix+"    ", annotate)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package avl
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test each print format
+func TestPrint(t *testing.T) {
+	tree := populateTree(t, []int{4, 2, 6, 1, 3, 5})
+	tree.Remove(5)
+
+	formats := map[PrintFormat]string{
+		PrintSorted: "1\n2\n3\n4\n6\n",
+		PrintStructure: "4\n" +
+			"├── L: 2\n" +
+			"│   ├── L: 1\n" +
+			"│   └── R: 3\n" +
+			"└── R: 6\n",
+		PrintBalance: "4 (h=2 bf=-1)\n" +
+			"├── L: 2 (h=1 bf=+0)\n" +
+			"│   ├── L: 1 (h=0 bf=+0)\n" +
+			"│   └── R: 3 (h=0 bf=+0)\n" +
+			"└── R: 6 (h=0 bf=+0)\n",
+	}
+	for format, expected := range formats {
+		var out strings.Builder
+		assert(tree.Print(&out, WithFormat(format)), nil, "tree.Print()", t)
+		assert(out.String(), expected, "tree.Print() output", t)
+	}
+
+	var out strings.Builder
+	tree.Print(&out)
+	assert(out.String(), formats[PrintSorted], "tree.Print() default format", t)
+
+	out.Reset()
+	NewAvlTree[int]().Print(&out, WithFormat(PrintStructure))
+	assert(out.String(), "", "empty tree Print()", t)
+
+	// Single children are drawn with the last-child branch
+	out.Reset()
+	populateTree(t, []int{2, 1}).Print(&out, WithFormat(PrintStructure))
+	assert(out.String(), "2\n└── L: 1\n", "tree.Print() with only a left child", t)
+}
@@ -0,0 +1,70 @@
+package avl
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Return the value at the given index in sorted order, the index-th smallest
+// counting from 0, in O(log n) using the subtree sizes kept on each node.
+// Returns an error wrapping ErrValueOutOfRange if the index is negative or
+// not less than Len.
+func (tree *AvlTree[T]) At(index int) (T, error) {
+	node := tree.nodeAt(index)
+	if node == nil {
+		var zero T
+		return zero, fmt.Errorf("index %d out of range for %d values: %w", index, tree.size, ErrValueOutOfRange)
+	}
+	return node.value, nil
+}
+
+// Returns up to limit values in order, starting from the value at index
+// offset, in O(log n + limit). Returns an empty slice if offset is out of
+// range or limit is not positive.
+func (tree *AvlTree[T]) Page(offset, limit int) []T {
+	page := make([]T, 0, max(0, min(limit, tree.size-offset)))
+	for node := tree.nodeAt(offset); node != nil && len(page) < limit; node = nextNode(node) {
+		page = append(page, node.value)
+	}
+	return page
+}
+
+// Returns an iterator over the tree's values in order, paired with their rank
+// (the number of values before them), so callers assigning positions don't
+// have to count. The tree must not be modified during iteration.
+func (tree *AvlTree[T]) AllWithRank() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		rank := 0
+		for node := tree.minNode(); node != nil; node = nextNode(node) {
+			if !yield(rank, node.value) {
+				return
+			}
+			rank += 1
+		}
+	}
+}
+
+// Returns the rank of the value: the number of values in the tree strictly
+// less than it, which is its index in sorted order if it is held, found in
+// O(log n). Also returns a bool indicating whether the value is in the tree.
+func (tree *AvlTree[T]) Rank(value T) (int, bool) {
+	node, rank, ok := tree.PartitionPoint(func(v T) bool { return tree.less(v, value) })
+	return rank, ok && tree.equal(node, value)
+}
+
+// Returns the number of values in the tree strictly less than value
+func (tree *AvlTree[T]) CountLess(value T) int {
+	_, rank, _ := tree.PartitionPoint(func(v T) bool { return tree.less(v, value) })
+	return rank
+}
+
+// Returns the number of values in the tree less than or equal to value
+func (tree *AvlTree[T]) CountLessOrEqual(value T) int {
+	_, rank, _ := tree.PartitionPoint(func(v T) bool { return !tree.less(value, v) })
+	return rank
+}
+
+// Returns the number of values in the tree strictly greater than value
+func (tree *AvlTree[T]) CountGreater(value T) int {
+	return tree.size - tree.CountLessOrEqual(value)
+}
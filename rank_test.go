@@ -0,0 +1,110 @@
+package avl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Test At against indexing the sorted values
+func TestAt(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		sorted := slices.Clone(testCase)
+		slices.Sort(sorted)
+		for i, expected := range sorted {
+			v, err := tree.At(i)
+			assert(err, nil, fmt.Sprintf("tree.At(%d) error", i), t)
+			assert(v, expected, fmt.Sprintf("tree.At(%d)", i), t)
+		}
+		for _, i := range []int{-1, len(sorted)} {
+			_, err := tree.At(i)
+			assert(errors.Is(err, ErrValueOutOfRange), true, fmt.Sprintf("tree.At(%d) out of range", i), t)
+		}
+	}
+}
+
+// Test Rank against counting the smaller values, for held values and values
+// between them
+func TestRank(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		for x := -12; x <= 55; x++ {
+			expected := 0
+			for _, v := range testCase {
+				if v < x {
+					expected += 1
+				}
+			}
+			rank, ok := tree.Rank(x)
+			assert(rank, expected, fmt.Sprintf("tree.Rank(%d)", x), t)
+			assert(ok, slices.Contains(testCase, x), fmt.Sprintf("tree.Rank(%d) ok", x), t)
+		}
+	}
+}
+
+// Test Page against slicing the sorted values
+func TestPage(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		sorted := slices.Clone(testCase)
+		slices.Sort(sorted)
+		for offset := -1; offset <= len(sorted)+1; offset++ {
+			for limit := -1; limit <= len(sorted)+1; limit++ {
+				expected := []int{}
+				if offset >= 0 && offset < len(sorted) && limit > 0 {
+					expected = sorted[offset:min(offset+limit, len(sorted))]
+				}
+				assertSlice(tree.Page(offset, limit), expected, fmt.Sprintf("tree.Page(%d, %d)", offset, limit), t)
+			}
+		}
+	}
+}
+
+// Test that AllWithRank pairs every value with its sorted position
+func TestAllWithRank(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		sorted := slices.Clone(testCase)
+		slices.Sort(sorted)
+		count := 0
+		for rank, v := range tree.AllWithRank() {
+			assert(rank, count, "tree.AllWithRank() rank", t)
+			assert(v, sorted[rank], "tree.AllWithRank() value", t)
+			count += 1
+		}
+		assert(count, len(sorted), "tree.AllWithRank() length", t)
+
+		// Stopping early
+		for rank := range tree.AllWithRank() {
+			if rank == 1 {
+				break
+			}
+		}
+	}
+}
+
+// Test the counting helpers against a linear count, including duplicates
+func TestCountHelpers(t *testing.T) {
+	for _, testCase := range append(slices.Clone(cases), []int{2, 2, 2, 1, 3, 3}) {
+		tree := populateTree(t, testCase)
+		for x := -12; x <= 55; x++ {
+			less, lessOrEqual, greater := 0, 0, 0
+			for _, v := range testCase {
+				if v < x {
+					less++
+				}
+				if v <= x {
+					lessOrEqual++
+				}
+				if v > x {
+					greater++
+				}
+			}
+			assert(tree.CountLess(x), less, fmt.Sprintf("tree.CountLess(%d)", x), t)
+			assert(tree.CountLessOrEqual(x), lessOrEqual, fmt.Sprintf("tree.CountLessOrEqual(%d)", x), t)
+			assert(tree.CountGreater(x), greater, fmt.Sprintf("tree.CountGreater(%d)", x), t)
+		}
+	}
+}
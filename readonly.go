@@ -0,0 +1,233 @@
+package avl
+
+import (
+	"io"
+	"iter"
+)
+
+// ReadOnlyTree is a view of an AvlTree that exposes only non-mutating
+// methods, so a tree can be handed to plugins or callbacks without allowing
+// them to modify it. The view reflects later changes made through the
+// underlying tree.
+type ReadOnlyTree[T any] struct {
+	tree *AvlTree[T]
+}
+
+// Returns a read-only view of the tree
+func (tree *AvlTree[T]) ReadOnly() ReadOnlyTree[T] {
+	return ReadOnlyTree[T]{tree: tree}
+}
+
+// Returns a bool indicating whether the value exists in the tree
+func (view ReadOnlyTree[T]) Contains(value T) bool {
+	return view.tree.Contains(value)
+}
+
+// Returns a bool indicating whether the tree is empty
+func (view ReadOnlyTree[T]) IsEmpty() bool {
+	return view.tree.IsEmpty()
+}
+
+// Return the minimum value in the tree
+func (view ReadOnlyTree[T]) GetMin() (T, error) {
+	return view.tree.GetMin()
+}
+
+// Return the maximum value in the tree
+func (view ReadOnlyTree[T]) GetMax() (T, error) {
+	return view.tree.GetMax()
+}
+
+// Return the height of the tree, or -1 for an empty tree
+func (view ReadOnlyTree[T]) Height() int {
+	return view.tree.Height()
+}
+
+// Return the tree's version, incremented by every mutation
+func (view ReadOnlyTree[T]) Version() uint64 {
+	return view.tree.Version()
+}
+
+// Return the number of nodes in the tree
+func (view ReadOnlyTree[T]) Size() int {
+	return view.tree.Size()
+}
+
+// Return the number of nodes in the tree
+func (view ReadOnlyTree[T]) Len() int {
+	return view.tree.Len()
+}
+
+// Return the minimum value in the tree
+func (view ReadOnlyTree[T]) Min() (T, error) {
+	return view.tree.Min()
+}
+
+// Return the maximum value in the tree
+func (view ReadOnlyTree[T]) Max() (T, error) {
+	return view.tree.Max()
+}
+
+// Returns the tree's values in-order, formatted like a slice
+func (view ReadOnlyTree[T]) String() string {
+	return view.tree.String()
+}
+
+// Returns a slice of the tree's values in-order
+func (view ReadOnlyTree[T]) InOrderTraverse() []T {
+	return view.tree.InOrderTraverse()
+}
+
+// Returns a new iterator for the tree
+func (view ReadOnlyTree[T]) NewIterator() *AvlTreeIterator[T] {
+	return view.tree.NewIterator()
+}
+
+// Returns an iterator over the tree's values in ascending order
+func (view ReadOnlyTree[T]) All() iter.Seq[T] {
+	return view.tree.All()
+}
+
+// Returns an iterator over the tree's values in descending order
+func (view ReadOnlyTree[T]) Backward() iter.Seq[T] {
+	return view.tree.Backward()
+}
+
+// Returns an iterator over the tree's values in ascending order that
+// tolerates changes to the tree during iteration. See AvlTree.AllWeak.
+func (view ReadOnlyTree[T]) AllWeak() iter.Seq[T] {
+	return view.tree.AllWeak()
+}
+
+// Returns an iterator over the tree's values starting from the given value.
+// See AvlTree.IterateFrom.
+func (view ReadOnlyTree[T]) IterateFrom(value T, inclusive, descending bool) iter.Seq[T] {
+	return view.tree.IterateFrom(value, inclusive, descending)
+}
+
+// Returns an iterator over the tree's values in order, paired with their rank
+func (view ReadOnlyTree[T]) AllWithRank() iter.Seq2[int, T] {
+	return view.tree.AllWithRank()
+}
+
+// Returns a source streaming the tree's values in ascending order
+func (view ReadOnlyTree[T]) Source() SortedSource[T] {
+	return view.tree.Source()
+}
+
+// Return the value at the given index in sorted order. See AvlTree.At.
+func (view ReadOnlyTree[T]) At(index int) (T, error) {
+	return view.tree.At(index)
+}
+
+// Returns up to limit values in order, starting from the value at index
+// offset
+func (view ReadOnlyTree[T]) Page(offset, limit int) []T {
+	return view.tree.Page(offset, limit)
+}
+
+// Returns the number of values less than the value, and whether it is in the
+// tree. See AvlTree.Rank.
+func (view ReadOnlyTree[T]) Rank(value T) (int, bool) {
+	return view.tree.Rank(value)
+}
+
+// Returns the number of values in the tree strictly less than value
+func (view ReadOnlyTree[T]) CountLess(value T) int {
+	return view.tree.CountLess(value)
+}
+
+// Returns the number of values in the tree less than or equal to value
+func (view ReadOnlyTree[T]) CountLessOrEqual(value T) int {
+	return view.tree.CountLessOrEqual(value)
+}
+
+// Returns the number of values in the tree strictly greater than value
+func (view ReadOnlyTree[T]) CountGreater(value T) int {
+	return view.tree.CountGreater(value)
+}
+
+// Returns the greatest value in the tree less than or equal to the given
+// value, or false if every value is greater
+func (view ReadOnlyTree[T]) Floor(value T) (T, bool) {
+	return view.tree.Floor(value)
+}
+
+// Returns the least value in the tree greater than or equal to the given
+// value, or false if every value is less
+func (view ReadOnlyTree[T]) Ceiling(value T) (T, bool) {
+	return view.tree.Ceiling(value)
+}
+
+// Returns the least value in the tree strictly greater than the given value,
+// or false if there is none
+func (view ReadOnlyTree[T]) Successor(value T) (T, bool) {
+	return view.tree.Successor(value)
+}
+
+// Returns the greatest value in the tree strictly less than the given value,
+// or false if there is none
+func (view ReadOnlyTree[T]) Predecessor(value T) (T, bool) {
+	return view.tree.Predecessor(value)
+}
+
+// Returns the floor and ceiling of the value found in one descent. See
+// AvlTree.FloorCeilingPair.
+func (view ReadOnlyTree[T]) FloorCeilingPair(value T) (floor, ceiling T, okFloor, okCeil bool) {
+	return view.tree.FloorCeilingPair(value)
+}
+
+// Returns the first value in order for which pred returns true. See
+// AvlTree.FirstWhere.
+func (view ReadOnlyTree[T]) FirstWhere(pred func(T) bool) (T, bool) {
+	return view.tree.FirstWhere(pred)
+}
+
+// Returns the last value in order for which pred returns true. See
+// AvlTree.LastWhere.
+func (view ReadOnlyTree[T]) LastWhere(pred func(T) bool) (T, bool) {
+	return view.tree.LastWhere(pred)
+}
+
+// Returns the first value in order for which less returns false, with its
+// rank. See AvlTree.PartitionPoint.
+func (view ReadOnlyTree[T]) PartitionPoint(less func(T) bool) (T, int, bool) {
+	return view.tree.PartitionPoint(less)
+}
+
+// Returns a slice reporting whether each of the values exists in the tree
+func (view ReadOnlyTree[T]) ContainsEach(values []T) []bool {
+	return view.tree.ContainsEach(values)
+}
+
+// Returns a frozen snapshot of the tree's values
+func (view ReadOnlyTree[T]) Freeze() *FrozenTree[T] {
+	return view.tree.Freeze()
+}
+
+// Returns the recorded mutations with a version greater than sinceVersion.
+// See AvlTree.ChangeLog.
+func (view ReadOnlyTree[T]) ChangeLog(sinceVersion uint64) ([]ChangeRecord[T], error) {
+	return view.tree.ChangeLog(sinceVersion)
+}
+
+// Returns the oldest version the change log has every later record for. See
+// AvlTree.OldestRetainedVersion.
+func (view ReadOnlyTree[T]) OldestRetainedVersion() uint64 {
+	return view.tree.OldestRetainedVersion()
+}
+
+// Returns the first corruption found by the height guard, or nil
+func (view ReadOnlyTree[T]) Err() error {
+	return view.tree.Err()
+}
+
+// Write the tree to w in the format selected by the options
+func (view ReadOnlyTree[T]) Print(w io.Writer, opts ...PrintOption) error {
+	return view.tree.Print(w, opts...)
+}
+
+// Write every node of the tree to w for bug reports. See AvlTree.DumpState.
+func (view ReadOnlyTree[T]) DumpState(w io.Writer) error {
+	return view.tree.DumpState(w)
+}
@@ -0,0 +1,53 @@
+package avl
+
+import (
+	"slices"
+	"testing"
+)
+
+// Test that a read-only view reflects the underlying tree
+func TestReadOnlyTree(t *testing.T) {
+	tree := populateTree(t, []int{3, 1, 2})
+	view := tree.ReadOnly()
+
+	assert(view.Contains(2), true, "view.Contains(2)", t)
+	assert(view.Size(), 3, "view.Size()", t)
+	assertSlice(view.InOrderTraverse(), []int{1, 2, 3}, "view.InOrderTraverse()", t)
+
+	tree.Add(4)
+	maxVal, err := view.GetMax()
+	assert(err, nil, "view.GetMax()", t)
+	assert(maxVal, 4, "view.GetMax() after tree.Add", t)
+
+	tree.Clear()
+	assert(view.IsEmpty(), true, "view.IsEmpty() after tree.Clear", t)
+	_, err = view.GetMin()
+	assert(err != nil, true, "view.GetMin() on empty tree", t)
+}
+
+// Test that the view forwards the tree's ordered reads
+func TestReadOnlyTreeQueries(t *testing.T) {
+	tree := populateTree(t, []int{10, 20, 30, 40})
+	view := tree.ReadOnly()
+
+	assertSlice(slices.Collect(view.All()), []int{10, 20, 30, 40}, "view.All()", t)
+	assertSlice(slices.Collect(view.Backward()), []int{40, 30, 20, 10}, "view.Backward()", t)
+	assertSlice(slices.Collect(view.IterateFrom(20, false, false)), []int{30, 40}, "view.IterateFrom()", t)
+	v, err := view.At(2)
+	assert(err == nil && v == 30, true, "view.At(2)", t)
+	assertSlice(view.Page(1, 2), []int{20, 30}, "view.Page(1, 2)", t)
+	rank, ok := view.Rank(30)
+	assert(ok && rank == 2, true, "view.Rank(30)", t)
+	v, ok = view.Floor(25)
+	assert(ok && v == 20, true, "view.Floor(25)", t)
+	v, ok = view.Ceiling(25)
+	assert(ok && v == 30, true, "view.Ceiling(25)", t)
+	_, ok = view.Successor(40)
+	assert(ok, false, "view.Successor(40)", t)
+	v, ok = view.FirstWhere(func(v int) bool { return v > 15 })
+	assert(ok && v == 20, true, "view.FirstWhere()", t)
+	_, rank, _ = view.PartitionPoint(func(v int) bool { return v < 35 })
+	assert(rank, 3, "view.PartitionPoint()", t)
+	assert(view.CountGreater(20), 2, "view.CountGreater(20)", t)
+	assert(view.Err(), nil, "view.Err()", t)
+}
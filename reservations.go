@@ -0,0 +1,123 @@
+package avl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ReservationBook books half-open intervals [lo, hi) of a shared resource,
+// such as time slots of a room or units of a rate limit, where each booking
+// states how many reservations may overlap at once. The load at a point is
+// the number of reservations covering it.
+//
+// The book keeps one CoalescingIntervalSet per level of load: the set at
+// level k covers the points with a load greater than k, so each set lies
+// within the one below. Finding the largest load over an interval takes
+// O(log L log n) for L levels, and reserving or releasing an interval takes
+// O(L log n) plus O(log n) per stored interval it changes.
+type ReservationBook[T Number] struct {
+	// Points with a load greater than the index. The first level is kept
+	// even when empty, and its tree reports misuse.
+	levels []*CoalescingIntervalSet[T]
+}
+
+// %% Public methods %%
+
+func NewReservationBook[T Number]() *ReservationBook[T] {
+	return &ReservationBook[T]{levels: []*CoalescingIntervalSet[T]{NewCoalescingIntervalSet[T]()}}
+}
+
+// Reserve the interval [lo, hi), unless it would make the load anywhere in it
+// greater than capacity.
+// Returns an error wrapping ErrCapacityExceeded if the interval already has a
+// load of capacity somewhere, in which case nothing is reserved, or one
+// wrapping ErrInvalidRange according to the misuse policy if hi is not
+// greater than lo.
+func (book *ReservationBook[T]) Reserve(lo, hi T, capacity int) error {
+	if !(lo < hi) {
+		return book.misuse(fmt.Errorf("%w: [%v, %v) is empty", ErrInvalidRange, lo, hi))
+	}
+	if load := book.MaxLoad(lo, hi); load >= capacity {
+		return fmt.Errorf("[%v, %v) has a load of %d with a capacity of %d: %w", lo, hi, load, capacity, ErrCapacityExceeded)
+	}
+	// The points of [lo, hi) at each level move up one, starting from the
+	// top so each level is read before it changes
+	for k := len(book.levels); k > 0; k-- {
+		if k == len(book.levels) {
+			if !book.levels[k-1].overlaps(lo, hi) {
+				continue
+			}
+			book.levels = append(book.levels, NewCoalescingIntervalSet[T]())
+		}
+		for start, end := range book.levels[k-1].clipped(lo, hi) {
+			book.levels[k].Insert(start, end)
+		}
+	}
+	book.levels[0].Insert(lo, hi)
+	return nil
+}
+
+// Release a reservation of [lo, hi), lowering the load of each point in it
+// by one. The interval need not match a single reservation, but every point
+// in it must be reserved.
+// Returns an error wrapping ErrInvalidRange according to the misuse policy
+// if hi is not greater than lo or part of the interval is not reserved, in
+// which case nothing is released.
+func (book *ReservationBook[T]) Release(lo, hi T) error {
+	if !(lo < hi) {
+		return book.misuse(fmt.Errorf("%w: [%v, %v) is empty", ErrInvalidRange, lo, hi))
+	}
+	for start, end := range book.levels[0].Gaps(lo, hi) {
+		return book.misuse(fmt.Errorf("%w: [%v, %v) is not reserved", ErrInvalidRange, start, end))
+	}
+	// The points of [lo, hi) at each level move down one, starting from the
+	// bottom so each level is read before it changes
+	for k, level := range book.levels {
+		level.Remove(lo, hi)
+		if k+1 < len(book.levels) {
+			for start, end := range book.levels[k+1].clipped(lo, hi) {
+				level.Insert(start, end)
+			}
+		}
+	}
+	for len(book.levels) > 1 && book.levels[len(book.levels)-1].Len() == 0 {
+		book.levels = book.levels[:len(book.levels)-1]
+	}
+	return nil
+}
+
+// Return the number of reservations covering the point x
+func (book *ReservationBook[T]) Load(x T) int {
+	return sort.Search(len(book.levels), func(k int) bool {
+		return !book.levels[k].Covered(x)
+	})
+}
+
+// Return the largest load of any point in [lo, hi), or 0 if hi is not
+// greater than lo
+func (book *ReservationBook[T]) MaxLoad(lo, hi T) int {
+	if !(lo < hi) {
+		return 0
+	}
+	return sort.Search(len(book.levels), func(k int) bool {
+		return !book.levels[k].overlaps(lo, hi)
+	})
+}
+
+// Release every reservation
+func (book *ReservationBook[T]) Clear() {
+	book.levels[0].Clear()
+	book.levels = book.levels[:1]
+}
+
+// Set how the book reports misuse, such as releasing an interval that is not
+// reserved. See MisusePolicy.
+func (book *ReservationBook[T]) SetMisusePolicy(policy MisusePolicy) {
+	book.levels[0].starts.SetMisusePolicy(policy)
+}
+
+// %%% Private methods %%%
+
+func (book *ReservationBook[T]) misuse(err error) error {
+	return book.levels[0].starts.misuse(err)
+}
@@ -0,0 +1,77 @@
+package avl
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// Test that reservations are refused once they would overlap more than the
+// capacity, and that releasing them frees the capacity again
+func TestReservationBook(t *testing.T) {
+	book := NewReservationBook[int]()
+	assert(book.Reserve(0, 10, 2), nil, "book.Reserve(0, 10, 2)", t)
+	assert(book.Reserve(5, 15, 2), nil, "book.Reserve(5, 15, 2)", t)
+	assert(book.MaxLoad(0, 20), 2, "book.MaxLoad(0, 20)", t)
+	assert(book.Load(7), 2, "book.Load(7)", t)
+	assert(book.Load(12), 1, "book.Load(12)", t)
+	assert(book.Load(15), 0, "book.Load(15)", t)
+
+	err := book.Reserve(8, 12, 2)
+	assert(errors.Is(err, ErrCapacityExceeded), true, "book.Reserve(8, 12, 2) over a full slot", t)
+	assert(book.Load(12), 1, "book.Load(12) after a refused reservation", t)
+	assert(book.Reserve(10, 20, 2), nil, "book.Reserve(10, 20, 2) after the first ends", t)
+	assert(book.Reserve(8, 12, 3), nil, "book.Reserve(8, 12, 3) with a larger capacity", t)
+	assert(book.MaxLoad(0, 20), 3, "book.MaxLoad(0, 20) after three overlap", t)
+
+	assert(book.Release(0, 10), nil, "book.Release(0, 10)", t)
+	assert(book.Release(8, 12), nil, "book.Release(8, 12)", t)
+	assert(book.MaxLoad(0, 10), 1, "book.MaxLoad(0, 10) after Release", t)
+	assert(book.Reserve(0, 10, 2), nil, "book.Reserve(0, 10, 2) after Release", t)
+
+	assert(errors.Is(book.Release(20, 25), ErrInvalidRange), true, "book.Release() of an unreserved interval", t)
+	assert(errors.Is(book.Reserve(5, 5, 1), ErrInvalidRange), true, "book.Reserve() of an empty interval", t)
+	book.SetMisusePolicy(PanicOnMisuse)
+	assert(panics(func() { book.Release(20, 25) }), true, "book.Release() of an unreserved interval panics", t)
+
+	book.Clear()
+	assert(book.MaxLoad(0, 20), 0, "book.MaxLoad(0, 20) after Clear", t)
+}
+
+// Test the book's loads against a count of reservations per point
+func TestReservationBookRandom(t *testing.T) {
+	const span, capacity = 40, 3
+	rng := rand.New(rand.NewSource(1))
+	book := NewReservationBook[int]()
+	loads := make([]int, span)
+	reserved := make([][2]int, 0)
+	for range 500 {
+		if len(reserved) > 0 && rng.Intn(3) == 0 {
+			i := rng.Intn(len(reserved))
+			r := reserved[i]
+			reserved = append(reserved[:i], reserved[i+1:]...)
+			assert(book.Release(r[0], r[1]), nil, "book.Release() of a reservation", t)
+			for x := r[0]; x < r[1]; x++ {
+				loads[x] -= 1
+			}
+		} else {
+			lo := rng.Intn(span)
+			hi := lo + 1 + rng.Intn(span-lo)
+			fits := true
+			for x := lo; x < hi; x++ {
+				fits = fits && loads[x] < capacity
+			}
+			err := book.Reserve(lo, hi, capacity)
+			assert(err == nil, fits, "book.Reserve() succeeds when the interval fits", t)
+			if fits {
+				reserved = append(reserved, [2]int{lo, hi})
+				for x := lo; x < hi; x++ {
+					loads[x] += 1
+				}
+			}
+		}
+		for x := range loads {
+			assert(book.Load(x), loads[x], "book.Load(x)", t)
+		}
+	}
+}
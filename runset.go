@@ -0,0 +1,177 @@
+package avl
+
+import (
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// RunSet is an ordered set of integers stored as maximal runs of consecutive
+// values, for dense sets such as ID ranges: a set holding 1 to 1,000,000 takes
+// one tree node instead of a million. Adding a value extends or merges the
+// runs next to it, and removing one shrinks or splits its run.
+//
+// Unlike AvlTree, a RunSet holds each value at most once. Len counts values in
+// an int, so runs must not hold more values than an int can count.
+type RunSet[T constraints.Integer] struct {
+	// Start of each run
+	starts *AvlTree[T]
+	// Last value of the run beginning at each start
+	ends map[T]T
+	size int
+}
+
+// %% Public methods %%
+
+func NewRunSet[T constraints.Integer]() *RunSet[T] {
+	return &RunSet[T]{starts: NewAvlTree[T](), ends: make(map[T]T)}
+}
+
+// Add the value to the set. Returns true if the value was added, false if it
+// was already in the set.
+func (set *RunSet[T]) Add(value T) bool {
+	if set.Contains(value) {
+		return false
+	}
+	set.AddRange(value, value)
+	return true
+}
+
+// Add every value from lo to hi inclusive, merging the runs they overlap or
+// touch into one. Does nothing if hi is less than lo.
+func (set *RunSet[T]) AddRange(lo, hi T) {
+	if hi < lo {
+		return
+	}
+	// A run ending just before lo is merged
+	if node := set.starts.floorNode(lo); node != nil && (lo == node.value || set.ends[node.value] >= lo-1) {
+		lo = node.value
+	}
+	// As is every run starting up to just after hi
+	for node := set.starts.ceilingNode(lo); node != nil && (node.value <= hi || node.value-1 == hi); node = set.starts.ceilingNode(lo) {
+		hi = max(hi, set.ends[node.value])
+		set.removeRun(node.value)
+	}
+	set.addRun(lo, hi)
+}
+
+// Remove the value from the set. Returns true on successful removal, false if
+// the value was not in the set.
+func (set *RunSet[T]) Remove(value T) bool {
+	start, end, ok := set.runAt(value)
+	if !ok {
+		return false
+	}
+	set.removeRun(start)
+	if start < value {
+		set.addRun(start, value-1)
+	}
+	if value < end {
+		set.addRun(value+1, end)
+	}
+	return true
+}
+
+// Returns a bool indicating whether the value is in the set
+func (set *RunSet[T]) Contains(value T) bool {
+	_, _, ok := set.runAt(value)
+	return ok
+}
+
+// Return the number of values in the set
+func (set *RunSet[T]) Len() int {
+	return set.size
+}
+
+// Return the number of runs the set is stored as
+func (set *RunSet[T]) RunCount() int {
+	return set.starts.Len()
+}
+
+// Return the minimum value in the set
+func (set *RunSet[T]) Min() (T, error) {
+	return set.starts.GetMin()
+}
+
+// Return the maximum value in the set
+func (set *RunSet[T]) Max() (T, error) {
+	start, err := set.starts.GetMax()
+	if err != nil {
+		var zero T
+		return zero, ErrEmptyTree
+	}
+	return set.ends[start], nil
+}
+
+// Returns an iterator over the runs of the set in ascending order, as the
+// first and last value of each run
+func (set *RunSet[T]) Runs() iter.Seq2[T, T] {
+	return func(yield func(T, T) bool) {
+		for node := set.starts.minNode(); node != nil; node = nextNode(node) {
+			if !yield(node.value, set.ends[node.value]) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over the values of the set in ascending order
+func (set *RunSet[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for start, end := range set.Runs() {
+			for v := start; ; v++ {
+				if !yield(v) {
+					return
+				}
+				if v == end {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Returns an iterator over the values of the set greater than or equal to
+// from and less than to, in ascending order
+func (set *RunSet[T]) Range(from, to T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		node := set.starts.floorNode(from)
+		if node == nil || set.ends[node.value] < from {
+			node = set.starts.ceilingNode(from)
+		}
+		for ; node != nil && node.value < to; node = nextNode(node) {
+			for v := max(node.value, from); v < to; v++ {
+				if !yield(v) {
+					return
+				}
+				if v == set.ends[node.value] {
+					break
+				}
+			}
+		}
+	}
+}
+
+// %%% Private methods %%%
+
+// Returns the run holding the value, if any
+func (set *RunSet[T]) runAt(value T) (T, T, bool) {
+	node := set.starts.floorNode(value)
+	if node == nil || set.ends[node.value] < value {
+		return 0, 0, false
+	}
+	return node.value, set.ends[node.value], true
+}
+
+func (set *RunSet[T]) addRun(start, end T) {
+	set.starts.Add(start)
+	set.ends[start] = end
+	set.size += int(end) - int(start) + 1
+}
+
+func (set *RunSet[T]) removeRun(start T) {
+	end := set.ends[start]
+	set.starts.Remove(start)
+	delete(set.ends, start)
+	set.size -= int(end) - int(start) + 1
+}
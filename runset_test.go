@@ -0,0 +1,95 @@
+package avl
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// Test that runs are merged and split as values are added and removed
+func TestRunSet(t *testing.T) {
+	set := NewRunSet[int]()
+	for _, v := range []int{5, 3, 4, 10, 1} {
+		set.Add(v)
+	}
+	assert(set.Add(4), false, "set.Add() of a held value", t)
+	assert(set.Len(), 5, "set.Len()", t)
+	assert(set.RunCount(), 3, "set.RunCount()", t)
+
+	set.AddRange(6, 9)
+	assert(set.RunCount(), 2, "set.RunCount() after AddRange joining runs", t)
+	assertSlice(slices.Collect(set.All()), []int{1, 3, 4, 5, 6, 7, 8, 9, 10}, "set.All()", t)
+
+	assert(set.Remove(7), true, "set.Remove() from the middle of a run", t)
+	assert(set.Remove(7), false, "set.Remove() of a missing value", t)
+	runs := make([][2]int, 0)
+	for start, end := range set.Runs() {
+		runs = append(runs, [2]int{start, end})
+	}
+	assertSlice(runs, [][2]int{{1, 1}, {3, 6}, {8, 10}}, "set.Runs() after split", t)
+	assertSlice(slices.Collect(set.Range(4, 9)), []int{4, 5, 6, 8}, "set.Range(4, 9)", t)
+
+	minVal, _ := set.Min()
+	maxVal, _ := set.Max()
+	assert(minVal, 1, "set.Min()", t)
+	assert(maxVal, 10, "set.Max()", t)
+
+	dense := NewRunSet[uint32]()
+	dense.AddRange(1, 1_000_000)
+	assert(dense.Len(), 1_000_000, "dense.Len()", t)
+	assert(dense.RunCount(), 1, "dense.RunCount()", t)
+}
+
+// Test runs touching the limits of the value type
+func TestRunSetLimits(t *testing.T) {
+	set := NewRunSet[int8]()
+	set.AddRange(math.MaxInt8-1, math.MaxInt8)
+	set.Add(math.MinInt8)
+	set.AddRange(math.MinInt8, math.MinInt8+2)
+	assert(set.RunCount(), 2, "set.RunCount()", t)
+	assertSlice(slices.Collect(set.All()), []int8{-128, -127, -126, 126, 127}, "set.All()", t)
+	assertSlice(slices.Collect(set.Range(100, math.MaxInt8)), []int8{126}, "set.Range() up to the maximum", t)
+	set.AddRange(math.MinInt8, math.MaxInt8)
+	assert(set.Len(), 256, "set.Len() of the whole type", t)
+	set.Remove(math.MaxInt8)
+	maxVal, _ := set.Max()
+	assert(maxVal, int8(126), "set.Max() after Remove", t)
+}
+
+// Test the set against a map under random operations
+func TestRunSetRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	set, model := NewRunSet[int](), make(map[int]bool)
+	for i := 0; i < 5000; i++ {
+		v := r.Intn(200)
+		switch r.Intn(3) {
+		case 0:
+			assert(set.Add(v), !model[v], "set.Add()", t)
+			model[v] = true
+		case 1:
+			assert(set.Remove(v), model[v], "set.Remove()", t)
+			delete(model, v)
+		case 2:
+			hi := v + r.Intn(10)
+			set.AddRange(v, hi)
+			for w := v; w <= hi; w++ {
+				model[w] = true
+			}
+		}
+	}
+	expected := make([]int, 0)
+	for v := range model {
+		expected = append(expected, v)
+	}
+	slices.Sort(expected)
+	assertSlice(slices.Collect(set.All()), expected, "set.All()", t)
+	assert(set.Len(), len(model), "set.Len()", t)
+
+	// Runs are maximal: no two of them touch
+	last := -2
+	for start, end := range set.Runs() {
+		assert(start > last+1, true, "runs are separated", t)
+		last = end
+	}
+}
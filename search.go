@@ -0,0 +1,146 @@
+package avl
+
+// Returns the first value in order for which pred returns true, or false if
+// there is none. pred must be monotone over the tree's order: false for some
+// (possibly empty) prefix of the values and true for all the rest, such as
+// func(v T) bool { return v >= x }. Under that contract the answer is found
+// by a single descent in O(log n); otherwise the result is unspecified.
+func (tree *AvlTree[T]) FirstWhere(pred func(T) bool) (T, bool) {
+	var found *Node[T]
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return tree.FirstWhere(pred)
+			}
+			return nodeValue[T](nil)
+		}
+		if pred(curr.value) {
+			found = curr
+			curr = curr.children[dirLeft]
+		} else {
+			curr = curr.children[dirRight]
+		}
+	}
+	return nodeValue(found)
+}
+
+// Returns the last value in order for which pred returns true, or false if
+// there is none. pred must be monotone over the tree's order: true for some
+// (possibly empty) prefix of the values and false for all the rest, such as
+// func(v T) bool { return v <= x }. Under that contract the answer is found
+// by a single descent in O(log n); otherwise the result is unspecified.
+func (tree *AvlTree[T]) LastWhere(pred func(T) bool) (T, bool) {
+	var found *Node[T]
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return tree.LastWhere(pred)
+			}
+			return nodeValue[T](nil)
+		}
+		if pred(curr.value) {
+			found = curr
+			curr = curr.children[dirRight]
+		} else {
+			curr = curr.children[dirLeft]
+		}
+	}
+	return nodeValue(found)
+}
+
+// Returns the greatest value in the tree less than or equal to the given
+// value, or false if every value is greater
+func (tree *AvlTree[T]) Floor(value T) (T, bool) {
+	return nodeValue(tree.floorNode(value))
+}
+
+// Returns the least value in the tree greater than or equal to the given
+// value, or false if every value is less
+func (tree *AvlTree[T]) Ceiling(value T) (T, bool) {
+	return nodeValue(tree.ceilingNode(value))
+}
+
+// Returns the least value in the tree strictly greater than the given value,
+// which need not be in the tree, or false if there is none
+func (tree *AvlTree[T]) Successor(value T) (T, bool) {
+	return nodeValue(tree.higherNode(value))
+}
+
+// Returns the greatest value in the tree strictly less than the given value,
+// which need not be in the tree, or false if there is none
+func (tree *AvlTree[T]) Predecessor(value T) (T, bool) {
+	return nodeValue(tree.lowerNode(value))
+}
+
+// Returns the greatest value less than or equal to the given value and the
+// least value greater than or equal to it, each with false if there is none.
+// Both bounds are found in the same descent, which ends early when the value
+// itself is in the tree, in which case it is both the floor and the ceiling.
+func (tree *AvlTree[T]) FloorCeilingPair(value T) (floor, ceiling T, okFloor, okCeil bool) {
+	var floorNode, ceilingNode *Node[T]
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return tree.FloorCeilingPair(value)
+			}
+			floorNode, ceilingNode = nil, nil
+			break
+		}
+		if tree.less(value, curr.value) {
+			ceilingNode = curr
+			curr = curr.children[dirLeft]
+		} else if tree.less(curr.value, value) {
+			floorNode = curr
+			curr = curr.children[dirRight]
+		} else {
+			floorNode, ceilingNode = curr, curr
+			break
+		}
+	}
+	floor, okFloor = nodeValue(floorNode)
+	ceiling, okCeil = nodeValue(ceilingNode)
+	return floor, ceiling, okFloor, okCeil
+}
+
+// Returns the node's value and true, or the zero value and false for nil
+func nodeValue[T any](node *Node[T]) (T, bool) {
+	if node == nil {
+		var zero T
+		return zero, false
+	}
+	return node.value, true
+}
+
+// Returns the first value in order for which less returns false, its rank
+// (the number of values before it) and true. If less is true for every value,
+// returns the zero value, the tree's size and false, mirroring sort.Search.
+// less must be true for some (possibly empty) prefix of the values and false
+// for all the rest, such as func(v T) bool { return v < x }. Subtree sizes
+// kept on each node make this a single O(log n) descent.
+func (tree *AvlTree[T]) PartitionPoint(less func(T) bool) (T, int, bool) {
+	var found *Node[T]
+	rank, index := tree.size, 0
+	curr := tree.root
+	for depth, limit := 0, tree.depthLimit(); curr != nil; depth++ {
+		if depth > limit {
+			if tree.detectedCorruption(depth, limit) == nil {
+				return tree.PartitionPoint(less)
+			}
+			var zero T
+			return zero, tree.size, false
+		}
+		if less(curr.value) {
+			index += nodeCount(curr.children[dirLeft]) + 1
+			curr = curr.children[dirRight]
+		} else {
+			found = curr
+			rank = index + nodeCount(curr.children[dirLeft])
+			curr = curr.children[dirLeft]
+		}
+	}
+	value, ok := nodeValue(found)
+	return value, rank, ok
+}
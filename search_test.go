@@ -0,0 +1,126 @@
+package avl
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// Test FirstWhere and LastWhere against a linear scan for threshold predicates
+func TestFirstLastWhere(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		sorted := slices.Clone(testCase)
+		slices.Sort(sorted)
+
+		for x := -12; x <= 55; x++ {
+			first, ok := tree.FirstWhere(func(v int) bool { return v >= x })
+			i := slices.IndexFunc(sorted, func(v int) bool { return v >= x })
+			assert(ok, i != -1, fmt.Sprintf("tree.FirstWhere(>= %d) found", x), t)
+			if ok {
+				assert(first, sorted[i], fmt.Sprintf("tree.FirstWhere(>= %d)", x), t)
+			}
+
+			last, ok := tree.LastWhere(func(v int) bool { return v <= x })
+			j := -1
+			for k, v := range sorted {
+				if v <= x {
+					j = k
+				}
+			}
+			assert(ok, j != -1, fmt.Sprintf("tree.LastWhere(<= %d) found", x), t)
+			if ok {
+				assert(last, sorted[j], fmt.Sprintf("tree.LastWhere(<= %d)", x), t)
+			}
+		}
+	}
+}
+
+// Test PartitionPoint against sort.Search over the sorted values
+func TestPartitionPoint(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		sorted := slices.Clone(testCase)
+		slices.Sort(sorted)
+
+		for x := -12; x <= 55; x++ {
+			value, rank, ok := tree.PartitionPoint(func(v int) bool { return v < x })
+			expected := sort.Search(len(sorted), func(i int) bool { return sorted[i] >= x })
+			assert(rank, expected, fmt.Sprintf("tree.PartitionPoint(< %d) rank", x), t)
+			assert(ok, expected < len(sorted), fmt.Sprintf("tree.PartitionPoint(< %d) found", x), t)
+			if ok {
+				assert(value, sorted[expected], fmt.Sprintf("tree.PartitionPoint(< %d)", x), t)
+			}
+		}
+	}
+}
+
+// Test Floor and Ceiling for held values, values between held ones and values
+// past either end
+func TestFloorCeiling(t *testing.T) {
+	tree := populateTree(t, []int{10, 20, 20, 30})
+	for _, tc := range []struct {
+		value           int
+		floor, ceiling  int
+		okFloor, okCeil bool
+	}{
+		{20, 20, 20, true, true},
+		{25, 20, 30, true, true},
+		{5, 0, 10, false, true},
+		{35, 30, 0, true, false},
+		{10, 10, 10, true, true},
+	} {
+		floor, ok := tree.Floor(tc.value)
+		assert(ok, tc.okFloor, fmt.Sprintf("tree.Floor(%d) ok", tc.value), t)
+		assert(floor, tc.floor, fmt.Sprintf("tree.Floor(%d)", tc.value), t)
+		ceiling, ok := tree.Ceiling(tc.value)
+		assert(ok, tc.okCeil, fmt.Sprintf("tree.Ceiling(%d) ok", tc.value), t)
+		assert(ceiling, tc.ceiling, fmt.Sprintf("tree.Ceiling(%d)", tc.value), t)
+	}
+
+	_, ok := NewAvlTree[int]().Floor(1)
+	assert(ok, false, "Floor() of an empty tree", t)
+}
+
+// Test that Successor and Predecessor skip past equal values, whether or not
+// the given value is held
+func TestSuccessorPredecessor(t *testing.T) {
+	tree := populateTree(t, []int{10, 20, 20, 30})
+	for _, tc := range []struct {
+		value                  int
+		successor, predecessor int
+		okSucc, okPred         bool
+	}{
+		{20, 30, 10, true, true},
+		{25, 30, 20, true, true},
+		{10, 20, 0, true, false},
+		{30, 0, 20, false, true},
+		{0, 10, 0, true, false},
+	} {
+		successor, ok := tree.Successor(tc.value)
+		assert(ok, tc.okSucc, fmt.Sprintf("tree.Successor(%d) ok", tc.value), t)
+		assert(successor, tc.successor, fmt.Sprintf("tree.Successor(%d)", tc.value), t)
+		predecessor, ok := tree.Predecessor(tc.value)
+		assert(ok, tc.okPred, fmt.Sprintf("tree.Predecessor(%d) ok", tc.value), t)
+		assert(predecessor, tc.predecessor, fmt.Sprintf("tree.Predecessor(%d)", tc.value), t)
+	}
+}
+
+// Test FloorCeilingPair against the single-bound descents
+func TestFloorCeilingPair(t *testing.T) {
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		for x := -12; x <= 55; x++ {
+			floor, ceiling, okFloor, okCeil := tree.FloorCeilingPair(x)
+			msg := fmt.Sprintf("tree.FloorCeilingPair(%d)", x)
+
+			expectedFloor, ok := nodeValue(tree.floorNode(x))
+			assert(okFloor, ok, msg+" okFloor", t)
+			assert(floor, expectedFloor, msg+" floor", t)
+			expectedCeiling, ok := nodeValue(tree.ceilingNode(x))
+			assert(okCeil, ok, msg+" okCeil", t)
+			assert(ceiling, expectedCeiling, msg+" ceiling", t)
+		}
+	}
+}
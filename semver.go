@@ -0,0 +1,159 @@
+package avl
+
+import "strings"
+
+// Returns a tree of semantic version strings such as "1.2.3" or
+// "v2.0.0-rc.1", ordered by SemverLess.
+func NewSemverTree(opts ...TreeOption) *AvlTree[string] {
+	return NewAvlTreeFunc(SemverLess, opts...)
+}
+
+// Returns a bool indicating whether version a sorts before version b under
+// the precedence rules of Semantic Versioning 2.0.0: major, minor and patch
+// compare numerically, a pre-release sorts before its release, and
+// pre-release identifiers compare one at a time, numeric ones numerically
+// and below alphanumeric ones. A leading "v" is allowed and build metadata
+// after "+" is ignored, so versions differing only in it are equal.
+// Strings that aren't valid versions sort after every valid one, in byte
+// order among themselves, which keeps the order total.
+func SemverLess(a, b string) bool {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	if !okA || !okB {
+		if okA != okB {
+			return okA
+		}
+		return a < b
+	}
+	for i := range va.core {
+		if c := compareNumeric(va.core[i], vb.core[i]); c != 0 {
+			return c < 0
+		}
+	}
+	// A release has higher precedence than any of its pre-releases
+	if va.pre == "" || vb.pre == "" {
+		return va.pre != "" && vb.pre == ""
+	}
+	return comparePrerelease(va.pre, vb.pre) < 0
+}
+
+type semver struct {
+	core [3]string
+	pre  string
+}
+
+// Split a version into its numeric core and pre-release, without allocating
+func parseSemver(s string) (semver, bool) {
+	var v semver
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		if !validIdentifiers(s[i+1:], false) {
+			return v, false
+		}
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.pre = s[i+1:]
+		if !validIdentifiers(v.pre, true) {
+			return v, false
+		}
+		s = s[:i]
+	}
+	for i := range v.core {
+		part := s
+		if i < len(v.core)-1 {
+			dot := strings.IndexByte(s, '.')
+			if dot < 0 {
+				return v, false
+			}
+			part, s = s[:dot], s[dot+1:]
+		}
+		if !isNumeric(part) || (len(part) > 1 && part[0] == '0') {
+			return v, false
+		}
+		v.core[i] = part
+	}
+	return v, true
+}
+
+// Check that s is a non-empty list of dot-separated identifiers. Numeric
+// pre-release identifiers must not have leading zeros.
+func validIdentifiers(s string, pre bool) bool {
+	for more := true; more; {
+		var id string
+		id, s, more = strings.Cut(s, ".")
+		if id == "" {
+			return false
+		}
+		for i := 0; i < len(id); i++ {
+			c := id[i]
+			if !(c == '-' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+				return false
+			}
+		}
+		if pre && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare two pre-releases identifier by identifier. When one runs out
+// first, the shorter one has lower precedence.
+func comparePrerelease(a, b string) int {
+	for a != "" && b != "" {
+		var idA, idB string
+		idA, a, _ = strings.Cut(a, ".")
+		idB, b, _ = strings.Cut(b, ".")
+		numA, numB := isNumeric(idA), isNumeric(idB)
+		var c int
+		switch {
+		case numA && numB:
+			c = compareNumeric(idA, idB)
+		case numA || numB:
+			// Numeric identifiers have lower precedence than alphanumeric ones
+			if numA {
+				c = -1
+			} else {
+				c = 1
+			}
+		default:
+			c = strings.Compare(idA, idB)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Compare two strings of digits without leading zeros by their value, so
+// they may be longer than any integer type
+func compareNumeric(a, b string) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
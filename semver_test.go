@@ -0,0 +1,59 @@
+package avl
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestSemverLess(t *testing.T) {
+	// In increasing precedence, from the examples in the specification
+	ordered := []string{
+		"0.9.99",
+		"1.0.0-0.3.7",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"v1.0.1",
+		"1.2.0",
+		"1.10.0",
+		"2.0.0",
+		"99999999999999999999.0.0",
+		"1.0",
+		"not a version",
+	}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			assert(SemverLess(a, b), i < j, fmt.Sprintf("SemverLess(%q, %q)", a, b), t)
+		}
+	}
+	assert(SemverLess("1.0.0+build.1", "1.0.0+build.2"), false, "build metadata should be ignored", t)
+	assert(SemverLess("1.0.0+build.2", "1.0.0"), false, "build metadata should be ignored", t)
+	assert(SemverLess("01.0.0", "1.0.0"), false, "leading zeros make a version invalid", t)
+	assert(SemverLess("1.0.0-01", "1.0.0-1"), false, "leading zeros make a pre-release invalid", t)
+	assert(SemverLess("1.0.0-", "1.0.0"), false, "an empty pre-release makes a version invalid", t)
+	assert(SemverLess("1.0.0-a..b", "1.0.0"), false, "an empty identifier makes a version invalid", t)
+
+	allocs := testing.AllocsPerRun(100, func() { SemverLess("v1.0.0-rc.1+build.5", "1.0.0-rc.2") })
+	assert(allocs, 0.0, "allocations per SemverLess call", t)
+}
+
+func TestNewSemverTree(t *testing.T) {
+	versions := []string{"1.0.0", "1.0.0-rc.1", "1.10.0", "1.2.0", "1.0.0-alpha", "1.0.0-beta.11", "1.0.0-beta.2"}
+	tree := NewSemverTree()
+	for _, i := range rand.New(rand.NewSource(1)).Perm(len(versions)) {
+		tree.Add(versions[i])
+	}
+	assertSlice(
+		tree.InOrderTraverse(),
+		[]string{"1.0.0-alpha", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.2.0", "1.10.0"},
+		"versions should be in precedence order", t,
+	)
+	assert(tree.Contains("v1.2.0"), true, "a leading v should compare equal", t)
+	assertBalanced(tree, "semver tree", t)
+}
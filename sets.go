@@ -0,0 +1,148 @@
+package avl
+
+import "slices"
+
+// Returns a slice reporting, for each of the given values, whether it exists
+// in the tree. The queries are sorted and answered in one in-order walk of
+// the tree, in O(n + m log m) rather than the O(m log n) of m calls to
+// Contains, which is faster when m is comparable to n.
+func (tree *AvlTree[T]) ContainsEach(values []T) []bool {
+	result := make([]bool, len(values))
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return tree.compare(values[a], values[b])
+	})
+
+	iter := tree.NewIterator()
+	curr, index := iter.Next()
+	for _, i := range order {
+		for index != -1 && tree.less(curr, values[i]) {
+			curr, index = iter.Next()
+		}
+		if index == -1 {
+			break
+		}
+		result[i] = tree.equal(curr, values[i])
+	}
+	return result
+}
+
+// Returns the number of values present in both trees, found by walking them
+// in parallel in O(n + m) without building the intersection. A value held k
+// times by one tree and l times by the other is counted min(k, l) times.
+// Both trees must order their values the same way.
+func IntersectionCount[T any](a, b *AvlTree[T]) int {
+	count := 0
+	iterA, iterB := a.NewIterator(), b.NewIterator()
+	x, i := iterA.Next()
+	y, j := iterB.Next()
+	for i != -1 && j != -1 {
+		switch {
+		case a.less(x, y):
+			x, i = iterA.Next()
+		case a.less(y, x):
+			y, j = iterB.Next()
+		default:
+			count += 1
+			x, i = iterA.Next()
+			y, j = iterB.Next()
+		}
+	}
+	return count
+}
+
+// Returns the Jaccard similarity of two trees, the size of their
+// intersection divided by the size of their union, between 0 and 1.
+// Two empty trees are considered identical and have a similarity of 1.
+func JaccardSimilarity[T any](a, b *AvlTree[T]) float64 {
+	intersection := IntersectionCount(a, b)
+	union := a.Size() + b.Size() - intersection
+	if union == 0 {
+		return 1
+	}
+	return float64(intersection) / float64(union)
+}
+
+// Returns a new tree holding the values of the tree and the source, such as
+// another tree's Source, built from a parallel walk of the two in O(n + m).
+// A value held k times by the tree and l times by the source is held
+// max(k, l) times, with the receiver's copies first; to keep every copy from
+// both, Merge the source into the tree instead. The source must be in the
+// tree's order, and the result orders its values like the receiver and takes
+// its settings: misuse policy, height guard and negative lookup filter.
+// Returns an error wrapping ErrInvalidArgument according to the misuse policy
+// if the source is out of order.
+func (tree *AvlTree[T]) Union(src SortedSource[T]) (*AvlTree[T], error) {
+	return tree.combine(src, true, true, true)
+}
+
+// Returns a new tree holding the values present in both the tree and the
+// source, built from a parallel walk of the two in O(n + m) rather than a
+// Contains per value. A value held k times by the tree and l times by the
+// source is held min(k, l) times, as IntersectionCount counts it. See Union
+// for the ordering and errors.
+func (tree *AvlTree[T]) Intersect(src SortedSource[T]) (*AvlTree[T], error) {
+	return tree.combine(src, false, true, false)
+}
+
+// Returns a new tree holding the values of the receiver that are not in the
+// source, built from a parallel walk of the two in O(n + m). A value held k
+// times by the receiver and l times by the source is held max(0, k - l)
+// times. See Union for the ordering and errors.
+func (tree *AvlTree[T]) Difference(src SortedSource[T]) (*AvlTree[T], error) {
+	return tree.combine(src, true, false, false)
+}
+
+// Returns a new tree built from a parallel walk of the tree and the source in
+// order, holding the values only in the receiver if onlyTree is set, the
+// values in both if inBoth is set, and the values only in the source if
+// onlyOther is set. Copies of a value are matched pairwise, so the surplus
+// copies of a value one side holds more often than the other count as only
+// in that side. The new tree takes the receiver's settings.
+func (tree *AvlTree[T]) combine(src SortedSource[T], onlyTree, inBoth, onlyOther bool) (*AvlTree[T], error) {
+	others, err := tree.readSorted(src)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]T, 0)
+	x, j := tree.minNode(), 0
+	for x != nil && j < len(others) {
+		switch y := others[j]; {
+		case tree.less(x.value, y):
+			if onlyTree {
+				values = append(values, x.value)
+			}
+			x = nextNode(x)
+		case tree.less(y, x.value):
+			if onlyOther {
+				values = append(values, y)
+			}
+			j += 1
+		default:
+			if inBoth {
+				values = append(values, x.value)
+			}
+			x, j = nextNode(x), j+1
+		}
+	}
+	for ; x != nil && onlyTree; x = nextNode(x) {
+		values = append(values, x.value)
+	}
+	if onlyOther {
+		values = append(values, others[j:]...)
+	}
+	if tree.clone != nil {
+		for i, v := range values {
+			values[i] = tree.clone(v)
+		}
+	}
+	combined := tree.emptyLike()
+	combined.replaceValues(values)
+	if combined.filter != nil {
+		combined.rebuildFilter()
+	}
+	return combined, nil
+}
@@ -0,0 +1,128 @@
+package avl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Test ContainsEach against individual Contains calls
+func TestContainsEach(t *testing.T) {
+	queries := rangeWithSteps(-12, 55, 1)
+	queries = append(queries, 3, -1, 3, 100)
+	for _, testCase := range cases {
+		tree := populateTree(t, testCase)
+		result := tree.ContainsEach(queries)
+		assert(len(result), len(queries), "len(tree.ContainsEach())", t)
+		for i, q := range queries {
+			assert(result[i], tree.Contains(q), fmt.Sprintf("tree.ContainsEach()[%d] (%d)", i, q), t)
+		}
+	}
+}
+
+// Test intersection counts and similarity of overlapping trees
+func TestIntersectionCount(t *testing.T) {
+	a := populateTree(t, rangeWithSteps(0, 30, 2))
+	b := populateTree(t, rangeWithSteps(0, 30, 3))
+	assert(IntersectionCount(a, b), 6, "IntersectionCount(a, b)", t)
+	assert(IntersectionCount(b, a), 6, "IntersectionCount(b, a)", t)
+	assert(JaccardSimilarity(a, b), 6.0/(16+11-6), "JaccardSimilarity(a, b)", t)
+
+	empty := NewAvlTree[int]()
+	assert(IntersectionCount(a, empty), 0, "IntersectionCount(a, empty)", t)
+	assert(JaccardSimilarity(a, empty), 0.0, "JaccardSimilarity(a, empty)", t)
+	assert(JaccardSimilarity(empty, NewAvlTree[int]()), 1.0, "JaccardSimilarity(empty, empty)", t)
+	assert(JaccardSimilarity(a, a), 1.0, "JaccardSimilarity(a, a)", t)
+
+	// Duplicates are matched pairwise
+	dupes := populateTree(t, []int{2, 2, 2, 4})
+	assert(IntersectionCount(dupes, populateTree(t, []int{2, 2, 4, 4})), 3, "IntersectionCount(duplicates)", t)
+}
+
+// Returns the tree built by a set operation, which only fails for a source
+// out of order
+func combined[T any](tree *AvlTree[T], err error) *AvlTree[T] {
+	if err != nil {
+		panic(err)
+	}
+	return tree
+}
+
+// Test Union against merging the sorted values, with duplicates matched
+// pairwise
+func TestUnion(t *testing.T) {
+	a := populateTree(t, []int{1, 2, 2, 2, 5, 9})
+	b := populateTree(t, []int{0, 2, 2, 5, 5, 10})
+	union := combined(a.Union(b.Source()))
+	assertSlice(union.InOrderTraverse(), []int{0, 1, 2, 2, 2, 5, 5, 9, 10}, "a.Union(b)", t)
+	assertBalanced(union, "a.Union(b)", t)
+	assertSlice(combined(b.Union(a.Source())).InOrderTraverse(), union.InOrderTraverse(), "b.Union(a)", t)
+	assert(union.Len(), a.Len()+b.Len()-IntersectionCount(a, b), "a.Union(b).Len()", t)
+
+	assertSlice(combined(a.Union(NewAvlTree[int]().Source())).InOrderTraverse(), a.InOrderTraverse(), "a.Union(empty)", t)
+	assertSlice(a.InOrderTraverse(), []int{1, 2, 2, 2, 5, 9}, "a after Union", t)
+
+	// Any sorted source can be combined with the tree
+	union = combined(a.Union(SliceSource([]int{2, 3, 9, 9})))
+	assertSlice(union.InOrderTraverse(), []int{1, 2, 2, 2, 3, 5, 9, 9}, "a.Union(slice)", t)
+	_, err := a.Union(SliceSource([]int{3, 2}))
+	assert(errors.Is(err, ErrInvalidArgument), true, "a.Union() of an unsorted source", t)
+	_, err = a.Intersect(SliceSource([]int{3, 2}))
+	assert(errors.Is(err, ErrInvalidArgument), true, "a.Intersect() of an unsorted source", t)
+	_, err = a.Difference(SliceSource([]int{3, 2}))
+	assert(errors.Is(err, ErrInvalidArgument), true, "a.Difference() of an unsorted source", t)
+}
+
+// Test Intersect against IntersectionCount and the expected common values
+func TestIntersect(t *testing.T) {
+	a := populateTree(t, []int{1, 2, 2, 2, 5, 9})
+	b := populateTree(t, []int{0, 2, 2, 5, 5, 10})
+	both := combined(a.Intersect(b.Source()))
+	assertSlice(both.InOrderTraverse(), []int{2, 2, 5}, "a.Intersect(b)", t)
+	assertBalanced(both, "a.Intersect(b)", t)
+	assert(both.Len(), IntersectionCount(a, b), "a.Intersect(b).Len()", t)
+
+	evens := populateTree(t, rangeWithSteps(0, 30, 2))
+	thirds := populateTree(t, rangeWithSteps(0, 30, 3))
+	assertSlice(combined(evens.Intersect(thirds.Source())).InOrderTraverse(), rangeWithSteps(0, 30, 6), "evens.Intersect(thirds)", t)
+	assert(combined(a.Intersect(NewAvlTree[int]().Source())).IsEmpty(), true, "a.Intersect(empty)", t)
+}
+
+// Test Difference, and that it partitions the receiver with Intersect
+func TestDifference(t *testing.T) {
+	a := populateTree(t, []int{1, 2, 2, 2, 5, 9})
+	b := populateTree(t, []int{0, 2, 2, 5, 5, 10})
+	diff := combined(a.Difference(b.Source()))
+	assertSlice(diff.InOrderTraverse(), []int{1, 2, 9}, "a.Difference(b)", t)
+	assertBalanced(diff, "a.Difference(b)", t)
+	assertSlice(combined(b.Difference(a.Source())).InOrderTraverse(), []int{0, 5, 10}, "b.Difference(a)", t)
+	diff.Merge(combined(a.Intersect(b.Source())).Source())
+	assertSlice(diff.InOrderTraverse(), a.InOrderTraverse(), "a.Difference(b) merged with a.Intersect(b)", t)
+
+	assertSlice(combined(a.Difference(NewAvlTree[int]().Source())).InOrderTraverse(), a.InOrderTraverse(), "a.Difference(empty)", t)
+	assert(combined(a.Difference(a.Source())).IsEmpty(), true, "a.Difference(a)", t)
+}
+
+// Test that the result of a set operation takes the receiver's settings
+func TestCombineKeepsSettings(t *testing.T) {
+	a := NewAvlTree[int](WithNegativeLookupFilter())
+	a.SetMisusePolicy(PanicOnMisuse)
+	a.SetHeightGuard(true)
+	for _, v := range []int{1, 2, 3} {
+		a.Add(v)
+	}
+	b := populateTree(t, []int{2, 3, 4})
+	for name, combined := range map[string]*AvlTree[int]{
+		"a.Union(b)":      combined(a.Union(b.Source())),
+		"a.Intersect(b)":  combined(a.Intersect(b.Source())),
+		"a.Difference(b)": combined(a.Difference(b.Source())),
+	} {
+		assert(combined.misusePolicy, PanicOnMisuse, name+" misuse policy", t)
+		assert(combined.heightGuard, true, name+" height guard", t)
+		assert(combined.filter != nil, true, name+" keeps a negative lookup filter", t)
+		for _, v := range combined.InOrderTraverse() {
+			assert(combined.Contains(v), true, name+" Contains each value", t)
+		}
+	}
+	assert(combined(b.Union(a.Source())).filter, (*negativeFilter[int])(nil), "b.Union(a) negative lookup filter", t)
+}
@@ -0,0 +1,153 @@
+package avl
+
+import "fmt"
+
+// SortedSource is a stream of values in ascending order, such as a tree's
+// values, a sorted file or a database cursor, that a tree can be merged with
+// in one pass.
+type SortedSource[T any] interface {
+	// Returns the next value and true, or the zero value and false once the
+	// source is exhausted
+	Next() (T, bool)
+}
+
+// Returns a source streaming the tree's values in ascending order. The
+// source must not be used after the tree is modified.
+func (tree *AvlTree[T]) Source() SortedSource[T] {
+	return &treeSource[T]{node: tree.minNode()}
+}
+
+type treeSource[T any] struct {
+	node *Node[T]
+}
+
+func (src *treeSource[T]) Next() (T, bool) {
+	if src.node == nil {
+		var zero T
+		return zero, false
+	}
+	value := src.node.value
+	src.node = nextNode(src.node)
+	return value, true
+}
+
+// Returns a source streaming the values of a slice, which must already be in
+// ascending order
+func SliceSource[T any](values []T) SortedSource[T] {
+	return &sliceSource[T]{values: values}
+}
+
+type sliceSource[T any] struct {
+	values []T
+}
+
+func (src *sliceSource[T]) Next() (T, bool) {
+	if len(src.values) == 0 {
+		var zero T
+		return zero, false
+	}
+	value := src.values[0]
+	src.values = src.values[1:]
+	return value, true
+}
+
+// Add every value from the source to the tree, merging the two sorted
+// sequences and rebuilding the tree in O(n + m). Values from the source are
+// placed after equal values already in the tree, as with Add, and each one is
+// recorded in the change log like an Add.
+// Returns an error wrapping ErrInvalidArgument according to the misuse policy
+// if the source is out of order or holds a nil value refused under
+// NilRejected, in which case the tree is left unchanged.
+func (tree *AvlTree[T]) Merge(src SortedSource[T]) error {
+	values, err := tree.readSorted(src)
+	if err != nil || len(values) == 0 {
+		return err
+	}
+	for _, v := range values {
+		if err := tree.checkValue(v); err != nil {
+			return tree.misuse(err)
+		}
+	}
+	if tree.clone != nil {
+		for i, v := range values {
+			values[i] = tree.clone(v)
+		}
+	}
+	current := &[]T{}
+	tree.inOrderTraverseHelper(tree.root, current)
+	tree.replaceValues(mergeSorted(*current, values, tree.less))
+	for _, v := range values {
+		tree.recordChange(ChangeAdd, v)
+	}
+	return nil
+}
+
+// Remove one copy of each value from the source from the tree, walking both
+// in order and rebuilding the tree in O(n + m). Values not in the tree are
+// skipped. Each removal is recorded in the change log like a Remove.
+// Returns the number of values removed, or an error wrapping
+// ErrInvalidArgument according to the misuse policy if the source is out of
+// order, in which case the tree is left unchanged.
+func (tree *AvlTree[T]) Subtract(src SortedSource[T]) (int, error) {
+	values, err := tree.readSorted(src)
+	if err != nil || len(values) == 0 {
+		return 0, err
+	}
+	kept := make([]T, 0, tree.size)
+	removed := make([]T, 0)
+	// Where each removed value is, once the ones before it are removed
+	ranks := make([]int, 0)
+	i := 0
+	for node := tree.minNode(); node != nil; node = nextNode(node) {
+		for i < len(values) && tree.less(values[i], node.value) {
+			i += 1
+		}
+		if i < len(values) && !tree.less(node.value, values[i]) {
+			removed = append(removed, node.value)
+			ranks = append(ranks, len(kept))
+			i += 1
+			continue
+		}
+		kept = append(kept, node.value)
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+	tree.replaceValues(kept)
+	for j, v := range removed {
+		tree.recordChangeAt(ChangeRemove, ranks[j], v)
+	}
+	return len(removed), nil
+}
+
+// Read every value from the source, reporting a source out of order as misuse
+func (tree *AvlTree[T]) readSorted(src SortedSource[T]) ([]T, error) {
+	values := make([]T, 0)
+	for v, ok := src.Next(); ok; v, ok = src.Next() {
+		if len(values) > 0 && tree.less(v, values[len(values)-1]) {
+			return nil, tree.misuse(fmt.Errorf("%w: source value %v follows %v", ErrInvalidArgument, v, values[len(values)-1]))
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// Replace the tree's nodes with a perfectly balanced tree of the given sorted
+// values, without recording any change
+func (tree *AvlTree[T]) replaceValues(values []T) {
+	tree.replaceRoot(buildFromSorted(values, nil))
+	tree.size = len(values)
+	tree.mayHoldDuplicates = tree.hasAdjacentEqual(values)
+	tree.height = subtreeHeight(tree.root)
+	tree.resetEdges()
+}
+
+// Returns a bool indicating whether any two adjacent sorted values are equal
+func (tree *AvlTree[T]) hasAdjacentEqual(values []T) bool {
+	for i := 1; i < len(values); i++ {
+		if !tree.less(values[i-1], values[i]) {
+			return true
+		}
+	}
+	return false
+}
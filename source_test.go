@@ -0,0 +1,53 @@
+package avl
+
+import (
+	"errors"
+	"testing"
+)
+
+// Test that a tree's source streams its values in order
+func TestTreeSource(t *testing.T) {
+	tree := populateTree(t, []int{3, 1, 2, 2})
+	src := tree.Source()
+	values := make([]int, 0)
+	for v, ok := src.Next(); ok; v, ok = src.Next() {
+		values = append(values, v)
+	}
+	assertSlice(values, []int{1, 2, 2, 3}, "values from tree.Source()", t)
+	_, ok := src.Next()
+	assert(ok, false, "src.Next() after the end", t)
+}
+
+// Test merging sources into a tree, from a slice and from another tree
+func TestMerge(t *testing.T) {
+	tree := populateTree(t, []int{1, 3, 5})
+	assert(tree.Merge(SliceSource([]int{0, 3, 4, 9})), nil, "tree.Merge()", t)
+	assertSlice(tree.InOrderTraverse(), []int{0, 1, 3, 3, 4, 5, 9}, "values after Merge", t)
+	assertBalanced(tree, "tree after Merge", t)
+	assert(tree.Version(), uint64(7), "tree.Version() after Merge", t)
+
+	other := populateTree(t, []int{2, 6})
+	assert(tree.Merge(other.Source()), nil, "tree.Merge(other.Source())", t)
+	assertSlice(tree.InOrderTraverse(), []int{0, 1, 2, 3, 3, 4, 5, 6, 9}, "values after merging a tree", t)
+	assertSlice(other.InOrderTraverse(), []int{2, 6}, "merged tree values", t)
+
+	err := tree.Merge(SliceSource([]int{8, 7}))
+	assert(errors.Is(err, ErrInvalidArgument), true, "tree.Merge() of an unsorted source", t)
+	assert(tree.Len(), 9, "tree.Len() after a failed Merge", t)
+}
+
+// Test subtracting a source, removing one copy per value
+func TestSubtract(t *testing.T) {
+	tree := populateTree(t, []int{1, 2, 2, 2, 3, 5, 8})
+	removed, err := tree.Subtract(SliceSource([]int{0, 2, 2, 4, 8, 9}))
+	assert(err, nil, "tree.Subtract() error", t)
+	assert(removed, 3, "tree.Subtract() removed", t)
+	assertSlice(tree.InOrderTraverse(), []int{1, 2, 3, 5}, "values after Subtract", t)
+	assertBalanced(tree, "tree after Subtract", t)
+
+	removed, _ = tree.Subtract(SliceSource([]int{4}))
+	assert(removed, 0, "tree.Subtract() of absent values", t)
+	_, err = tree.Subtract(SliceSource([]int{5, 1}))
+	assert(errors.Is(err, ErrInvalidArgument), true, "tree.Subtract() of an unsorted source", t)
+	assertSlice(tree.InOrderTraverse(), []int{1, 2, 3, 5}, "values after a failed Subtract", t)
+}
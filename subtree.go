@@ -0,0 +1,258 @@
+package avl
+
+import (
+	"fmt"
+	"math"
+)
+
+// Remove the subtree rooted at the given node and return its values as a new,
+// balanced tree. The receiver is rebalanced as the values are removed, and the
+// removals are recorded in its change log like any other Remove.
+// Returns an error if the node is nil or doesn't belong to the tree.
+func (tree *AvlTree[T]) DetachSubtree(node *Node[T]) (*AvlTree[T], error) {
+	if node == nil {
+		return nil, tree.misuse(fmt.Errorf("node is nil: %w", ErrInvalidNode))
+	}
+	if !tree.ownsNode(node) {
+		return nil, tree.misuse(fmt.Errorf("node does not belong to this tree: %w", ErrInvalidNode))
+	}
+
+	nodes := make([]*Node[T], 0)
+	collectNodes(node, &nodes)
+	values := make([]T, len(nodes))
+	for i, n := range nodes {
+		values[i] = n.value
+	}
+
+	// Removing the nodes one at a time keeps the receiver balanced; cutting
+	// the subtree off whole could leave an ancestor too unbalanced for a
+	// single rotation to repair
+	for _, n := range nodes {
+		tree.removeNode(n)
+	}
+
+	detached := newTree(tree.less)
+	detached.root = buildFromSorted(values, nil)
+	detached.size = len(values)
+	detached.mayHoldDuplicates = tree.hasAdjacentEqual(values)
+	detached.height = subtreeHeight(detached.root)
+	detached.resetEdges()
+	return detached, nil
+}
+
+// Append the nodes of the subtree rooted at node in-order
+func collectNodes[T any](node *Node[T], nodes *[]*Node[T]) {
+	if node == nil {
+		return
+	}
+	collectNodes(node.children[dirLeft], nodes)
+	*nodes = append(*nodes, node)
+	collectNodes(node.children[dirRight], nodes)
+}
+
+// Merge the values of another tree into this one, leaving the other tree
+// empty. When every value of one tree sorts before every value of the other,
+// the other tree's nodes are joined onto the receiver in O(log n); otherwise
+// both trees are merged in O(n + m) and the receiver is rebuilt. Values from
+// the other tree are placed after equal values already in the receiver. If
+// the receiver has a clone func, see WithCloneFunc, the other tree's values
+// are copied with it as they are adopted, which takes O(m) for m values.
+// Returns an error if other is nil or is the receiver itself.
+func (tree *AvlTree[T]) Graft(other *AvlTree[T]) error {
+	if other == nil {
+		return tree.misuse(fmt.Errorf("other tree is nil: %w", ErrInvalidArgument))
+	}
+	if other == tree {
+		return tree.misuse(fmt.Errorf("cannot graft a tree onto itself: %w", ErrInvalidArgument))
+	}
+	if other.root == nil {
+		return nil
+	}
+
+	if tree.clone != nil {
+		for node := other.minNode(); node != nil; node = nextNode(node) {
+			node.value = tree.clone(node.value)
+		}
+	}
+	var grafted []T
+	if tree.log != nil || tree.filter != nil {
+		grafted = other.InOrderTraverse()
+	}
+	count := other.size
+	duplicates := tree.mayHoldDuplicates || other.mayHoldDuplicates
+
+	if tree.root == nil {
+		tree.replaceRoot(other.root)
+	} else if !tree.less(other.minNode().value, tree.maxNode().value) {
+		duplicates = duplicates || !tree.less(tree.maxNode().value, other.minNode().value)
+		tree.join(tree.root, other.root)
+	} else if tree.less(other.maxNode().value, tree.minNode().value) {
+		tree.join(other.root, tree.root)
+	} else {
+		merged := mergeSorted(tree.InOrderTraverse(), other.InOrderTraverse(), tree.less)
+		duplicates = tree.hasAdjacentEqual(merged)
+		tree.root = buildFromSorted(merged, nil)
+	}
+	tree.mayHoldDuplicates = duplicates
+	tree.size += count
+	tree.height = subtreeHeight(tree.root)
+	tree.resetEdges()
+	other.Clear()
+
+	if grafted == nil {
+		tree.version += uint64(count)
+	}
+	for _, v := range grafted {
+		tree.recordChange(ChangeAdd, v)
+	}
+	return nil
+}
+
+// Join two subtrees into the receiver, where every value in left sorts before
+// or equal to every value in right. The minimum of right is used as the pivot.
+func (tree *AvlTree[T]) join(left, right *Node[T]) {
+	tree.replaceRoot(right)
+	pivot := subtreeEdge(right, dirLeft)
+	tree.unlinkNode(pivot)
+	tree.joinWith(left, pivot, tree.root, [2]int{subtreeHeight(left), subtreeHeight(tree.root)})
+}
+
+// Join two subtrees of the given heights and a pivot node between them into
+// the receiver. The pivot is linked in where the heights of the two sides
+// match, then the tree is rebalanced from there up to the root, in time
+// proportional to the difference in height of the two sides.
+func (tree *AvlTree[T]) joinWith(left, pivot, right *Node[T], heights [2]int) {
+	pivot.children, pivot.parent = [2]*Node[T]{}, nil
+
+	// Heights along the spines follow from the balance factors, so only the
+	// heights at the top are needed
+	sides := [2]*Node[T]{left, right}
+	if heights[dirLeft]-heights[dirRight] <= 1 && heights[dirRight]-heights[dirLeft] <= 1 {
+		pivot.setChildren(left, right)
+		pivot.balance = int8(heights[dirRight] - heights[dirLeft])
+		tree.replaceRoot(pivot)
+		tree.height = max(heights[dirLeft], heights[dirRight]) + 1
+		return
+	}
+
+	// Descend the inner spine of the taller side to a node as tall as the
+	// shorter side, and put the pivot there with the shorter side under it
+	tall := dirLeft
+	if heights[dirRight] > heights[dirLeft] {
+		tall = dirRight
+	}
+	inner := tall.opposite()
+	shortHeight := heights[inner]
+	tree.replaceRoot(sides[tall])
+	tree.height = heights[tall]
+	actionNode, height := sides[tall], heights[tall]
+	var growth int
+	for {
+		childHeight := height - 1 - max(int(actionNode.balance)*tall.sign(), 0)
+		if childHeight <= shortHeight+1 {
+			var children [2]*Node[T]
+			children[tall], children[inner] = actionNode.children[inner], sides[inner]
+			pivot.setChildren(children[dirLeft], children[dirRight])
+			pivot.balance = int8((shortHeight - childHeight) * inner.sign())
+			growth = max(childHeight, shortHeight) + 1 - childHeight
+			break
+		}
+		actionNode, height = actionNode.children[inner], childHeight
+	}
+	actionNode.children[inner] = pivot
+	pivot.parent = actionNode
+
+	// In a balanced tree the pivot's subtree is one level taller than the
+	// one it replaced
+	for curr := actionNode; curr != nil; curr = curr.parent {
+		curr.update()
+	}
+	tree.retrace(actionNode, inner, growth)
+}
+
+// Merge two sorted slices, taking from a first when values are equal
+func mergeSorted[T any](a, b []T, less func(a, b T) bool) []T {
+	merged := make([]T, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if less(b[j], a[i]) {
+			merged = append(merged, b[j])
+			j++
+		} else {
+			merged = append(merged, a[i])
+			i++
+		}
+	}
+	merged = append(merged, a[i:]...)
+	return append(merged, b[j:]...)
+}
+
+// Rotate the subtree rooted at node to the left, making the node's right
+// child the new root of the subtree, and fix up parent links, balance factors
+// and subtree sizes up to the root. Intended for experimenting with restructuring
+// heuristics: the tree stays ordered, but the rotation can leave it
+// unbalanced, which later operations don't necessarily repair. Iterators over
+// the tree fail fast after a rotation, but since the values are unchanged the
+// tree's version is not bumped and nothing is recorded in the change log.
+// Returns an error if the node is nil, doesn't belong to the tree or has no
+// right child, or if the tree is too tall for its balance factors to be
+// stored after the rotation, or for the height guard if it is on.
+func (tree *AvlTree[T]) RotateLeftAt(node *Node[T]) error {
+	return tree.rotateAt(node, dirLeft)
+}
+
+// Rotate the subtree rooted at node to the right, making the node's left
+// child the new root of the subtree. See RotateLeftAt.
+// Returns an error if the node is nil, doesn't belong to the tree or has no
+// left child.
+func (tree *AvlTree[T]) RotateRightAt(node *Node[T]) error {
+	return tree.rotateAt(node, dirRight)
+}
+
+func (tree *AvlTree[T]) rotateAt(node *Node[T], dir direction) error {
+	if err := tree.checkRotation(node, node != nil && node.children[dir.opposite()] != nil); err != nil {
+		return err
+	}
+	parent := node.parent
+	replacement, growth := node.rotate(dir)
+	tree.replaceSubtree(parent, node, replacement, growth)
+	tree.reshapes += 1
+	return nil
+}
+
+func (tree *AvlTree[T]) checkRotation(node *Node[T], hasChild bool) error {
+	if node == nil {
+		return tree.misuse(fmt.Errorf("node is nil: %w", ErrInvalidNode))
+	}
+	if !tree.ownsNode(node) {
+		return tree.misuse(fmt.Errorf("node does not belong to this tree: %w", ErrInvalidNode))
+	}
+	if !hasChild {
+		return tree.misuse(fmt.Errorf("node has no child to rotate into its place: %w", ErrInvalidNode))
+	}
+	// A node's balance factor is bounded by the height of its subtree, which
+	// a rotation can increase by one
+	if tree.Height() >= math.MaxInt8 {
+		return fmt.Errorf("tree is too tall to rotate: %w", ErrCapacityExceeded)
+	}
+	// Nor may it take the tree past the depth the height guard allows, or
+	// the guard would report the tree as corrupt
+	if tree.Height()+1 > tree.depthLimit() {
+		return fmt.Errorf("rotation could make the tree taller than the height guard allows: %w", ErrCapacityExceeded)
+	}
+	return nil
+}
+
+// Link a rotated subtree in place of the old one and update the ancestors
+// for the change in its height, without rebalancing them
+func (tree *AvlTree[T]) replaceSubtree(parent, old, replacement *Node[T], growth int) {
+	replacement.parent = parent
+	tree.replaceChild(parent, old, replacement)
+	for child := replacement; parent != nil; child, parent = parent, parent.parent {
+		if growth != 0 {
+			growth = parent.adjustBalance(parent.sideOf(child), growth)
+		}
+		parent.update()
+	}
+	tree.height += growth
+}
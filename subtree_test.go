@@ -0,0 +1,143 @@
+package avl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Test detaching every subtree of every test case
+func TestDetachSubtree(t *testing.T) {
+	for _, testCase := range cases {
+		for _, v := range testCase {
+			tree := populateTree(t, testCase)
+			node := tree.FindNode(v)
+			expectedDetached := make([]int, 0)
+			tree.inOrderTraverseHelper(node, &expectedDetached)
+
+			detached, err := tree.DetachSubtree(node)
+			assert(err, nil, "tree.DetachSubtree()", t)
+			assertSlice(detached.InOrderTraverse(), expectedDetached, "detached values", t)
+			assert(detached.Size(), len(expectedDetached), "detached.Size()", t)
+			assertBalanced(detached, "detached tree", t)
+
+			expectedRemaining := slices.Clone(testCase)
+			slices.Sort(expectedRemaining)
+			expectedRemaining = slices.DeleteFunc(expectedRemaining, func(x int) bool {
+				return slices.Contains(expectedDetached, x)
+			})
+			assertSlice(tree.InOrderTraverse(), expectedRemaining, "remaining values", t)
+			assert(tree.Size(), len(expectedRemaining), "tree.Size() after DetachSubtree", t)
+			assertBalanced(tree, "remaining tree", t)
+		}
+	}
+}
+
+// Test that nodes from other trees and nil nodes are rejected
+func TestDetachSubtreeInvalidNode(t *testing.T) {
+	tree := populateTree(t, []int{1, 2, 3})
+	other := populateTree(t, []int{1, 2, 3})
+
+	_, err := tree.DetachSubtree(nil)
+	assert(err != nil, true, "tree.DetachSubtree(nil)", t)
+	_, err = tree.DetachSubtree(other.FindNode(2))
+	assert(err != nil, true, "tree.DetachSubtree(other node)", t)
+	assert(tree.Size(), 3, "tree.Size() after failed DetachSubtree", t)
+}
+
+// Test grafting trees with disjoint and overlapping ranges of many sizes
+func TestGraft(t *testing.T) {
+	ranges := [][2][]int{
+		{rangeWithSteps(1, 50, 1), rangeWithSteps(51, 53, 1)},     // Short right
+		{rangeWithSteps(1, 3, 1), rangeWithSteps(4, 60, 1)},       // Short left
+		{rangeWithSteps(100, 130, 1), rangeWithSteps(1, 20, 1)},   // Other before
+		{rangeWithSteps(1, 20, 1), rangeWithSteps(20, 40, 1)},     // Touching
+		{rangeWithSteps(1, 40, 2), rangeWithSteps(2, 40, 2)},      // Interleaved
+		{rangeWithSteps(1, 10, 1), {}},                            // Empty other
+		{{}, rangeWithSteps(1, 10, 1)},                            // Empty receiver
+		{rangeWithSteps(1, 1, 1), rangeWithSteps(2, 2, 1)},        // Single nodes
+		{rangeWithSteps(1, 100, 1), rangeWithSteps(200, 200, 1)},  // Single pivot
+		{rangeWithSteps(50, 100, 1), rangeWithSteps(-50, 49, 10)}, // Sparse before
+	}
+	for _, r := range ranges {
+		tree := populateTree(t, r[0])
+		other := populateTree(t, r[1])
+		since := tree.EnableChangeLog()
+		follower := populateTree(t, tree.InOrderTraverse())
+
+		assert(tree.Graft(other), nil, "tree.Graft(other)", t)
+
+		expected := append(slices.Clone(r[0]), r[1]...)
+		slices.Sort(expected)
+		assertSlice(tree.InOrderTraverse(), expected, "tree after Graft", t)
+		assert(tree.Size(), len(expected), "tree.Size() after Graft", t)
+		assertBalanced(tree, "tree after Graft", t)
+		assert(other.IsEmpty(), true, "other.IsEmpty() after Graft", t)
+
+		follower.ApplyChanges(changesSince(t, tree, since))
+		assertSlice(follower.InOrderTraverse(), expected, "follower after Graft", t)
+	}
+}
+
+// Test that detached subtrees can be grafted back
+func TestGraftDetached(t *testing.T) {
+	values := rangeWithSteps(1, 31, 1)
+	tree := populateTree(t, values)
+	detached, _ := tree.DetachSubtree(tree.getRootNode().children[dirLeft])
+	assert(tree.Graft(detached), nil, "tree.Graft(detached)", t)
+	assertSlice(tree.InOrderTraverse(), values, "tree after Graft", t)
+	assertBalanced(tree, "tree after Graft", t)
+
+	assert(tree.Graft(tree) != nil, true, "tree.Graft(tree)", t)
+	assert(tree.Graft(nil) != nil, true, "tree.Graft(nil)", t)
+}
+
+// Test that rotations keep the tree ordered and can be undone
+func TestRotateAt(t *testing.T) {
+	values := rangeWithSteps(1, 20, 1)
+	tree := populateTree(t, values)
+	for _, v := range values {
+		node := tree.FindNode(v)
+		before := shapeString(tree.root)
+
+		if node.children[dirRight] != nil {
+			assert(tree.RotateLeftAt(node), nil, fmt.Sprintf("tree.RotateLeftAt(%d)", v), t)
+			assertSlice(tree.InOrderTraverse(), values, "tree after RotateLeftAt", t)
+			assert(nodeCount(tree.root), len(values), "root count after RotateLeftAt", t)
+			assert(tree.RotateRightAt(node.parent), nil, "tree.RotateRightAt(node.parent)", t)
+		} else {
+			assert(tree.RotateLeftAt(node) != nil, true, fmt.Sprintf("tree.RotateLeftAt(%d) without right child", v), t)
+		}
+		assert(shapeString(tree.root), before, "shape after rotating back", t)
+		assertBalanced(tree, "tree after rotating back", t)
+	}
+
+	other := populateTree(t, values)
+	assert(tree.RotateRightAt(other.root) != nil, true, "tree.RotateRightAt(other node)", t)
+	assert(tree.RotateRightAt(nil) != nil, true, "tree.RotateRightAt(nil)", t)
+
+	// Rotations invalidate iterators, but leave the version to value changes
+	version := tree.Version()
+	iter := tree.NewIterator()
+	assert(tree.RotateLeftAt(tree.root), nil, "tree.RotateLeftAt(tree.root)", t)
+	assert(tree.Version(), version, "tree.Version() after RotateLeftAt", t)
+	assert(iter.Err(), ErrConcurrentModification, "iter.Err() after RotateLeftAt", t)
+
+	// With the height guard on, rotations stop at the depth it allows
+	guarded := populateTree(t, values)
+	guarded.SetHeightGuard(true)
+	// Rotate the left spine towards a chain until the guard refuses
+	var err error
+	for err == nil {
+		node := guarded.root
+		for node.children[dirRight] == nil && node.children[dirLeft] != nil {
+			node = node.children[dirLeft]
+		}
+		err = guarded.RotateLeftAt(node)
+	}
+	assert(errors.Is(err, ErrCapacityExceeded), true, "rotating past the height guard", t)
+	assert(guarded.Height() <= guarded.depthLimit(), true, "guarded.Height() within the guard", t)
+	guarded.Contains(0)
+	assert(guarded.Err(), nil, "guarded.Err() after rotations", t)
+}
@@ -0,0 +1,300 @@
+package avl
+
+import (
+	"context"
+	"iter"
+	"sync"
+
+	"golang.org/x/exp/constraints"
+)
+
+// SyncAvlTree is an AvlTree guarded by a read-write mutex, safe for
+// concurrent use by multiple goroutines. Reads may run in parallel, while
+// each write, or each Update, has exclusive access to the tree.
+type SyncAvlTree[T constraints.Ordered] struct {
+	mu   sync.RWMutex
+	tree *AvlTree[T]
+	// Pending writes for the background applier, nil unless batching
+	queue   chan queuedOp[T]
+	stopped chan struct{}
+	// Closed on the next write to wake goroutines in WaitFor, nil while
+	// nobody is waiting
+	changed chan struct{}
+}
+
+// queuedOp is a write waiting in the batching queue, or a flush marker that
+// closes done once every write queued before it has been applied
+type queuedOp[T constraints.Ordered] struct {
+	op    ChangeOp
+	value T
+	done  chan struct{}
+}
+
+// Maximum number of queued writes applied under a single lock acquisition
+const maxBatchSize = 256
+
+// Tx is a handle to a SyncAvlTree that is valid only for the duration of the
+// Update call it was passed to.
+type Tx[T constraints.Ordered] struct {
+	tree *AvlTree[T]
+}
+
+// Returns a new, empty thread-safe tree
+func NewSyncAvlTree[T constraints.Ordered]() *SyncAvlTree[T] {
+	return &SyncAvlTree[T]{tree: NewAvlTree[T]()}
+}
+
+// Insert a value into the tree
+func (st *SyncAvlTree[T]) Add(value T) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.tree.Add(value)
+	st.notify()
+}
+
+// Remove a value from the tree.
+// Returns true on successful removal, false if value was not found.
+func (st *SyncAvlTree[T]) Remove(value T) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	defer st.notify()
+	return st.tree.Remove(value)
+}
+
+// Clear the tree, removing all nodes
+func (st *SyncAvlTree[T]) Clear() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.tree.Clear()
+	st.notify()
+}
+
+// Returns a bool indicating whether the value exists in the tree
+func (st *SyncAvlTree[T]) Contains(value T) bool {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.Contains(value)
+}
+
+// Return the number of nodes in the tree
+func (st *SyncAvlTree[T]) Len() int {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.Len()
+}
+
+// Return the minimum value in the tree
+func (st *SyncAvlTree[T]) Min() (T, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.Min()
+}
+
+// Return the maximum value in the tree
+func (st *SyncAvlTree[T]) Max() (T, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.Max()
+}
+
+// Returns a slice of the tree's values in-order
+func (st *SyncAvlTree[T]) InOrderTraverse() []T {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.InOrderTraverse()
+}
+
+// Call fn with exclusive access to the tree. Every modification made through
+// the transaction handle becomes visible to other goroutines at once, when fn
+// returns. The handle must not be used after fn returns.
+func (st *SyncAvlTree[T]) Update(fn func(tx *Tx[T])) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	tx := &Tx[T]{tree: st.tree}
+	defer func() {
+		tx.tree = nil
+		st.notify()
+	}()
+	fn(tx)
+}
+
+// Call fn with a read-only view of the tree that no other goroutine modifies
+// until fn returns, for consistent reads across several calls. The view must
+// not be used after fn returns.
+func (st *SyncAvlTree[T]) View(fn func(view ReadOnlyTree[T])) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	fn(st.tree.ReadOnly())
+}
+
+// Returns a weakly consistent iterator over the tree's values in ascending
+// order, with the guarantees of AvlTree.AllWeak, that other goroutines may
+// write to while it runs. The read lock is held only while each step looks up
+// the next value and released before the value is yielded, so writers are
+// never blocked for the length of the loop, and the loop body may itself
+// write to the tree.
+func (st *SyncAvlTree[T]) AllWeak() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		st.mu.RLock()
+		value, err := st.tree.GetMin()
+		st.mu.RUnlock()
+		seen, ok := 0, err == nil
+		for ok && yield(value) {
+			st.mu.RLock()
+			value, seen, ok = st.tree.weakStep(value, seen+1)
+			st.mu.RUnlock()
+		}
+	}
+}
+
+// Block until the value is in the tree, returning nil, or until ctx is done,
+// returning ctx.Err(). Waiting goroutines are woken by every write to the
+// tree rather than polling Contains.
+func (st *SyncAvlTree[T]) WaitFor(ctx context.Context, value T) error {
+	for {
+		st.mu.Lock()
+		if st.tree.Contains(value) {
+			st.mu.Unlock()
+			return nil
+		}
+		if st.changed == nil {
+			st.changed = make(chan struct{})
+		}
+		changed := st.changed
+		st.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Wake every goroutine in WaitFor. The write lock must be held.
+func (st *SyncAvlTree[T]) notify() {
+	if st.changed != nil {
+		close(st.changed)
+		st.changed = nil
+	}
+}
+
+// Insert a value into the tree
+func (tx *Tx[T]) Add(value T) {
+	tx.tree.Add(value)
+}
+
+// Remove a value from the tree.
+// Returns true on successful removal, false if value was not found.
+func (tx *Tx[T]) Remove(value T) bool {
+	return tx.tree.Remove(value)
+}
+
+// Returns a bool indicating whether the value exists in the tree
+func (tx *Tx[T]) Contains(value T) bool {
+	return tx.tree.Contains(value)
+}
+
+// Return the number of nodes in the tree
+func (tx *Tx[T]) Len() int {
+	return tx.tree.Len()
+}
+
+// Start a background goroutine that applies writes made with QueueAdd and
+// QueueRemove in batches, taking the lock once per batch to amortize its
+// cost under very high write rates. At most capacity writes can be pending;
+// queueing blocks while the queue is full. Queued writes become visible to
+// readers some time later; call Flush to wait for them.
+// Must not be called concurrently with other batching methods.
+func (st *SyncAvlTree[T]) StartBatching(capacity int) {
+	if st.queue != nil {
+		return
+	}
+	st.queue = make(chan queuedOp[T], capacity)
+	st.stopped = make(chan struct{})
+	go st.applyQueued(st.queue, st.stopped)
+}
+
+// Flush pending writes and stop the background goroutine started by
+// StartBatching. Must not be called concurrently with other batching methods.
+func (st *SyncAvlTree[T]) StopBatching() {
+	if st.queue == nil {
+		return
+	}
+	close(st.queue)
+	<-st.stopped
+	st.queue, st.stopped = nil, nil
+}
+
+// Queue a value to be inserted by the background goroutine. Without
+// batching started, the value is inserted immediately.
+func (st *SyncAvlTree[T]) QueueAdd(value T) {
+	st.enqueue(queuedOp[T]{op: ChangeAdd, value: value})
+}
+
+// Queue a value to be removed by the background goroutine. Without
+// batching started, the value is removed immediately.
+func (st *SyncAvlTree[T]) QueueRemove(value T) {
+	st.enqueue(queuedOp[T]{op: ChangeRemove, value: value})
+}
+
+// Block until every write queued before the call has been applied
+func (st *SyncAvlTree[T]) Flush() {
+	if st.queue == nil {
+		return
+	}
+	done := make(chan struct{})
+	st.queue <- queuedOp[T]{done: done}
+	<-done
+}
+
+func (st *SyncAvlTree[T]) enqueue(op queuedOp[T]) {
+	if st.queue == nil {
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		st.apply(op)
+		st.notify()
+		return
+	}
+	st.queue <- op
+}
+
+// Apply queued writes until the queue is closed, draining whatever is
+// pending into each batch
+func (st *SyncAvlTree[T]) applyQueued(queue chan queuedOp[T], stopped chan struct{}) {
+	defer close(stopped)
+	for op := range queue {
+		batch := []queuedOp[T]{op}
+	drain:
+		for len(batch) < maxBatchSize {
+			select {
+			case next, ok := <-queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+
+		st.mu.Lock()
+		for _, op := range batch {
+			st.apply(op)
+		}
+		st.notify()
+		st.mu.Unlock()
+	}
+}
+
+// Apply a queued write, or signal a flush marker. The lock must be held.
+func (st *SyncAvlTree[T]) apply(op queuedOp[T]) {
+	switch {
+	case op.done != nil:
+		close(op.done)
+	case op.op == ChangeAdd:
+		st.tree.Add(op.value)
+	case op.op == ChangeRemove:
+		st.tree.Remove(op.value)
+	}
+}
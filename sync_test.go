@@ -0,0 +1,228 @@
+package avl
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Test that transactions are applied atomically with respect to readers
+func TestSyncAvlTreeUpdate(t *testing.T) {
+	tree := NewSyncAvlTree[int]()
+	tree.Add(0)
+
+	// Each transaction moves the single value from i to i+1, so readers
+	// must always see exactly one value
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			tree.Update(func(tx *Tx[int]) {
+				tx.Remove(i)
+				tx.Add(i + 1)
+			})
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		tree.View(func(view ReadOnlyTree[int]) {
+			assert(view.Len(), 1, "view.Len() during Update", t)
+		})
+		assert(len(tree.InOrderTraverse()), 1, "tree.InOrderTraverse() during Update", t)
+	}
+	wg.Wait()
+
+	assert(tree.Contains(200), true, "tree.Contains(200)", t)
+	assert(tree.Len(), 1, "tree.Len()", t)
+}
+
+// Test the basic methods of the thread-safe tree
+func TestSyncAvlTree(t *testing.T) {
+	tree := NewSyncAvlTree[string]()
+	for _, v := range []string{"tahini", "za'atar", "chickpeas"} {
+		tree.Add(v)
+	}
+	minVal, _ := tree.Min()
+	maxVal, _ := tree.Max()
+	assert(minVal, "chickpeas", "tree.Min()", t)
+	assert(maxVal, "za'atar", "tree.Max()", t)
+	assert(tree.Remove("tahini"), true, "tree.Remove()", t)
+	assertSlice(tree.InOrderTraverse(), []string{"chickpeas", "za'atar"}, "tree.InOrderTraverse()", t)
+
+	var tx *Tx[string]
+	tree.Update(func(handle *Tx[string]) {
+		tx = handle
+		assert(handle.Contains("chickpeas"), true, "tx.Contains()", t)
+	})
+	assert(tx.tree == nil, true, "tx invalidated after Update", t)
+
+	tree.Clear()
+	assert(tree.Len(), 0, "tree.Len() after Clear", t)
+}
+
+// Test queued writes from many goroutines are applied by Flush
+func TestSyncAvlTreeBatching(t *testing.T) {
+	tree := NewSyncAvlTree[int]()
+	tree.QueueAdd(-1) // Applied immediately without batching
+	assert(tree.Contains(-1), true, "tree.QueueAdd() without batching", t)
+
+	tree.StartBatching(8)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w * 100; i < (w+1)*100; i++ {
+				tree.QueueAdd(i)
+				if i%2 == 1 {
+					tree.QueueRemove(i)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	tree.Flush()
+	assert(tree.Len(), 201, "tree.Len() after Flush", t)
+
+	tree.QueueRemove(-1)
+	tree.StopBatching()
+	assert(tree.Contains(-1), false, "tree.Contains(-1) after StopBatching", t)
+	assertSlice(tree.InOrderTraverse(), rangeWithSteps(0, 398, 2), "tree after batching", t)
+}
+
+// Test waiting for a value to be added by another goroutine
+func TestSyncAvlTreeWaitFor(t *testing.T) {
+	tree := NewSyncAvlTree[int]()
+	tree.Add(1)
+	assert(tree.WaitFor(context.Background(), 1), nil, "tree.WaitFor(present value)", t)
+
+	done := make(chan error)
+	go func() {
+		done <- tree.WaitFor(context.Background(), 3)
+	}()
+	tree.Add(2)
+	tree.Update(func(tx *Tx[int]) { tx.Add(3) })
+	assert(<-done, nil, "tree.WaitFor(added value)", t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert(tree.WaitFor(ctx, 4), context.DeadlineExceeded, "tree.WaitFor(missing value)", t)
+}
+
+// Hammer the thread-safe tree from many goroutines with a random mix of
+// operations, including iteration inside View and batched writes, so that
+// running the tests with -race covers every code path of the sync variant
+func TestSyncAvlTreeStress(t *testing.T) {
+	goroutines, iterations := 8, 2000
+	if testing.Short() {
+		iterations = 200
+	}
+	tree := NewSyncAvlTree[int]()
+	tree.StartBatching(16)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for i := 0; i < iterations; i++ {
+				v := rng.Intn(64)
+				switch rng.Intn(10) {
+				case 0:
+					tree.Add(v)
+				case 1:
+					tree.Remove(v)
+				case 2:
+					tree.Contains(v)
+				case 3:
+					tree.Min()
+					tree.Max()
+				case 4:
+					values := tree.InOrderTraverse()
+					if !slices.IsSorted(values) {
+						t.Errorf("tree.InOrderTraverse() not sorted: %v", values)
+					}
+				case 5:
+					tree.View(func(view ReadOnlyTree[int]) {
+						iter := view.NewIterator()
+						for _, index := iter.Next(); index != -1; _, index = iter.Next() {
+						}
+					})
+				case 6:
+					tree.Update(func(tx *Tx[int]) {
+						if !tx.Contains(v) {
+							tx.Add(v)
+						}
+						tx.Remove(v + 1)
+					})
+				case 7:
+					tree.QueueAdd(v)
+				case 8:
+					tree.QueueRemove(v)
+				case 9:
+					ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+					tree.WaitFor(ctx, v)
+					cancel()
+				}
+			}
+		}(int64(g))
+	}
+	wg.Wait()
+	tree.StopBatching()
+	assertBalanced(tree.tree, "tree after stress", t)
+}
+
+// Test that the weak iterator runs alongside concurrent writers, and from a
+// loop body that writes, keeping its guarantees: ascending order, and every
+// value present throughout is visited
+func TestSyncAvlTreeAllWeak(t *testing.T) {
+	tree := NewSyncAvlTree[int]()
+	// Even values stay put, odd ones come and go
+	for v := 0; v < 1000; v += 2 {
+		tree.Add(v)
+	}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				v := 2*rng.Intn(500) + 1
+				if rng.Intn(2) == 0 {
+					tree.Add(v)
+				} else {
+					tree.Remove(v)
+				}
+			}
+		}(int64(g))
+	}
+
+	for round := 0; round < 20; round++ {
+		prev, evens := -1, 0
+		for v := range tree.AllWeak() {
+			if v < prev {
+				t.Fatalf("AllWeak() visited %d after %d", v, prev)
+			}
+			if v%2 == 0 {
+				evens += 1
+			} else if round%2 == 0 {
+				tree.Remove(v)
+			}
+			prev = v
+		}
+		assert(evens, 500, "AllWeak() visits every value present throughout", t)
+	}
+	close(stop)
+	wg.Wait()
+}
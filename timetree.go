@@ -0,0 +1,41 @@
+package avl
+
+import (
+	"iter"
+	"time"
+)
+
+// Times are ordered by time.Time.Before, i.e. by the instant they denote:
+// the same instant in two locations is one value. Times carrying monotonic
+// clock readings are compared by those readings, as time.Time.Compare does,
+// so strip them with Round(0) if a tree mixes them with times without.
+
+// Returns a tree of times in chronological order
+func NewTimeTree(opts ...TreeOption) *AvlTree[time.Time] {
+	return NewAvlTreeFunc(time.Time.Before, opts...)
+}
+
+// Returns a new, empty map keyed by time, in chronological order. Its Range
+// method gives the entries between two times.
+func NewTimeMap[V any]() *AvlMap[time.Time, V] {
+	return NewAvlMapFunc[time.Time, V](time.Time.Before)
+}
+
+// Returns an iterator over the times in the tree at or after from and
+// before to, in chronological order. The tree must be ordered by
+// time.Time.Before, as trees from NewTimeTree are.
+func TimeRange(tree *AvlTree[time.Time], from, to time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		for node := tree.ceilingNode(from); node != nil && node.value.Before(to); node = nextNode(node) {
+			if !yield(node.value) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the number of times in the tree at or after from and before to, in
+// O(log n). See TimeRange.
+func CountTimeRange(tree *AvlTree[time.Time], from, to time.Time) int {
+	return max(tree.CountLess(to)-tree.CountLess(from), 0)
+}
@@ -0,0 +1,38 @@
+package avl
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// Test time trees and maps, and ranges of times
+func TestTimeTree(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tree := NewTimeTree()
+	for _, h := range []int{5, 1, 3, 4, 2, 0} {
+		tree.Add(base.Add(time.Duration(h) * time.Hour))
+	}
+	assertBalanced(tree, "time tree", t)
+	tokyo := base.In(time.FixedZone("JST", 9*60*60))
+	assert(tree.Contains(tokyo), true, "the same instant in another location should be found", t)
+
+	from, to := base.Add(time.Hour), base.Add(4*time.Hour)
+	got := slices.Collect(TimeRange(tree, from, to))
+	assert(len(got), 3, "TimeRange should include from and exclude to", t)
+	assert(got[0].Equal(from), true, "TimeRange should start at from", t)
+	assert(CountTimeRange(tree, from, to), 3, "CountTimeRange(from, to)", t)
+	assert(CountTimeRange(tree, to, from), 0, "CountTimeRange with to before from", t)
+
+	m := NewTimeMap[string]()
+	m.Put(base.Add(2*time.Hour), "lunch")
+	m.Put(base, "standup")
+	m.Put(base.Add(5*time.Hour), "retro")
+	m.Put(tokyo, "standup in Tokyo")
+	assert(m.Len(), 3, "the same instant should be one key", t)
+	values := make([]string, 0)
+	for _, v := range m.Range(base, base.Add(3*time.Hour)) {
+		values = append(values, v)
+	}
+	assertSlice(values, []string{"standup in Tokyo", "lunch"}, "m.Range(from, to)", t)
+}
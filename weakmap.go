@@ -0,0 +1,128 @@
+//go:build go1.24
+
+package avl
+
+import (
+	"iter"
+	"runtime"
+	"sync"
+	"weak"
+
+	"golang.org/x/exp/constraints"
+)
+
+// WeakMap is an ordered map from keys to weakly held values: the map doesn't
+// keep its values alive, and once the garbage collector reclaims a value its
+// entry is purged from the map. This lets the map index live objects, such as
+// open sessions by ID, without having to be told when each one goes away.
+//
+// Entries are purged by cleanups the runtime runs on its own goroutine some
+// time after a value is collected, so the map is guarded by a mutex and is
+// safe for concurrent use. Until an entry is purged, Get reports its key as
+// missing but Len still counts it.
+//
+// WeakMap needs Go 1.24 or later.
+type WeakMap[K constraints.Ordered, V any] struct {
+	mu      sync.Mutex
+	entries *AvlMap[K, weakEntry[V]]
+}
+
+type weakEntry[V any] struct {
+	ptr     weak.Pointer[V]
+	cleanup runtime.Cleanup
+}
+
+// The argument passed to a value's cleanup. It must not refer to the value
+// itself, or the value would never be collected.
+type weakKey[K constraints.Ordered, V any] struct {
+	key K
+	ptr weak.Pointer[V]
+}
+
+// %% Public methods %%
+
+// Returns a new, empty weak map
+func NewWeakMap[K constraints.Ordered, V any]() *WeakMap[K, V] {
+	return &WeakMap[K, V]{entries: NewAvlMap[K, weakEntry[V]]()}
+}
+
+// Set the value for the key without keeping it alive, replacing any value the
+// key already had. The entry is purged once the value is collected. A nil
+// value can't be collected, so putting nil deletes the key instead.
+func (m *WeakMap[K, V]) Put(key K, value *V) {
+	if value == nil {
+		m.Delete(key)
+		return
+	}
+	ptr := weak.Make(value)
+	cleanup := runtime.AddCleanup(value, m.purge, weakKey[K, V]{key: key, ptr: ptr})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.entries.Get(key); ok {
+		old.cleanup.Stop()
+	}
+	m.entries.Put(key, weakEntry[V]{ptr: ptr, cleanup: cleanup})
+}
+
+// Returns the value for the key, or nil and false if the key is not in the
+// map or its value has been collected
+func (m *WeakMap[K, V]) Get(key K) (*V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries.Get(key)
+	if !ok {
+		return nil, false
+	}
+	value := entry.ptr.Value()
+	return value, value != nil
+}
+
+// Remove the key from the map.
+// Returns true on successful removal, false if the key was not found.
+func (m *WeakMap[K, V]) Delete(key K) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries.Get(key)
+	if !ok {
+		return false
+	}
+	entry.cleanup.Stop()
+	return m.entries.Delete(key)
+}
+
+// Return the number of entries in the map, including any whose values have
+// been collected but not yet purged
+func (m *WeakMap[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.entries.Len()
+}
+
+// Returns an iterator over the map's live entries in ascending order of key.
+// The map is locked for the whole iteration, so the loop body must not use
+// the map.
+func (m *WeakMap[K, V]) All() iter.Seq2[K, *V] {
+	return func(yield func(K, *V) bool) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for key, entry := range m.entries.All() {
+			value := entry.ptr.Value()
+			if value != nil && !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// %%% Private methods %%%
+
+// Remove an entry whose value was collected, unless the key has since been
+// given another value
+func (m *WeakMap[K, V]) purge(collected weakKey[K, V]) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.entries.Get(collected.key); ok && entry.ptr == collected.ptr {
+		m.entries.Delete(collected.key)
+	}
+}
@@ -0,0 +1,77 @@
+//go:build go1.24
+
+package avl
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+type session struct {
+	id  string
+	buf [64]byte
+}
+
+// Collect garbage until the map has the expected number of entries, giving
+// the runtime time to run cleanups
+func awaitLen(m *WeakMap[string, session], expected int, t *testing.T) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if m.Len() == expected {
+			return
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("m.Len() = %d, expected %d after collection", m.Len(), expected)
+}
+
+// Test that entries are purged once their values are collected, and only
+// those entries
+func TestWeakMap(t *testing.T) {
+	m := NewWeakMap[string, session]()
+	kept := &session{id: "kept"}
+	m.Put("kept", kept)
+	for _, id := range []string{"a", "b", "c"} {
+		m.Put(id, &session{id: id})
+	}
+	assert(m.Len(), 4, "m.Len() before collection", t)
+
+	awaitLen(m, 1, t)
+	value, ok := m.Get("kept")
+	assert(ok, true, "m.Get(\"kept\") ok", t)
+	assert(value.id, "kept", "m.Get(\"kept\")", t)
+	_, ok = m.Get("a")
+	assert(ok, false, "m.Get(\"a\") after collection", t)
+
+	// A replaced value's cleanup must not purge the new one
+	m.Put("kept", &session{id: "dropped"})
+	m.Put("kept", kept)
+	runtime.GC()
+	awaitLen(m, 1, t)
+	keys := make([]string, 0)
+	for key := range m.All() {
+		keys = append(keys, key)
+	}
+	assertSlice(keys, []string{"kept"}, "keys after replacing", t)
+
+	assert(m.Delete("kept"), true, "m.Delete(\"kept\")", t)
+	assert(m.Len(), 0, "m.Len() after Delete", t)
+	runtime.KeepAlive(kept)
+}
+
+// Test that putting nil deletes the key rather than registering a cleanup
+func TestWeakMapPutNil(t *testing.T) {
+	m := NewWeakMap[string, session]()
+	kept := &session{id: "kept"}
+	m.Put("kept", kept)
+	m.Put("kept", nil)
+	assert(m.Len(), 0, "m.Len() after m.Put(\"kept\", nil)", t)
+	_, ok := m.Get("kept")
+	assert(ok, false, "m.Get(\"kept\") after m.Put(\"kept\", nil)", t)
+
+	m.Put("missing", nil)
+	assert(m.Len(), 0, "m.Len() after putting nil for a missing key", t)
+	runtime.KeepAlive(kept)
+}